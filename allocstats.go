@@ -0,0 +1,44 @@
+// +build immutablestats
+
+package immutable
+
+import (
+	"sync/atomic"
+)
+
+// Storage node allocation counters, only available when building
+// with the immutablestats tag.
+var (
+	bucketAllocs     uint64
+	vectorNodeAllocs uint64
+)
+
+// NodeAllocations returns the number of map buckets and vector
+// nodes allocated since the last call to ResetNodeAllocations.
+// Only available when building with the immutablestats tag.
+func NodeAllocations() (buckets, vectorNodes uint64) {
+	return atomic.LoadUint64(&bucketAllocs), atomic.LoadUint64(&vectorNodeAllocs)
+}
+
+// ResetNodeAllocations resets the node allocation counters.
+// Only available when building with the immutablestats tag.
+func ResetNodeAllocations() {
+	atomic.StoreUint64(&bucketAllocs, 0)
+	atomic.StoreUint64(&vectorNodeAllocs, 0)
+}
+
+func countBucketAlloc() {
+	atomic.AddUint64(&bucketAllocs, 1)
+}
+
+func countVectorNodeAlloc() {
+	atomic.AddUint64(&vectorNodeAllocs, 1)
+}
+
+// fingerprints counts computed vector fingerprints, letting tests
+// verify fingerprint caching.
+var fingerprints uint64
+
+func countFingerprint() {
+	atomic.AddUint64(&fingerprints, 1)
+}
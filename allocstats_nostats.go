@@ -0,0 +1,9 @@
+// +build !immutablestats
+
+package immutable
+
+func countBucketAlloc() {}
+
+func countVectorNodeAlloc() {}
+
+func countFingerprint() {}
@@ -0,0 +1,86 @@
+// +build immutablestats
+
+package immutable
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestMapSetAllocations(t *testing.T) {
+	var m Map
+	for i := 0; i < 100000; i++ {
+		m = m.Set(i, i)
+	}
+
+	ResetNodeAllocations()
+	m.Set(-1, -1)
+	buckets, _ := NodeAllocations()
+	if buckets != uint64(levels) {
+		t.Fail()
+	}
+}
+
+func TestMapBuilderAllocations(t *testing.T) {
+	var m Map
+	for i := 0; i < 100000; i++ {
+		m = m.Set(i, i)
+	}
+
+	b := m.Builder()
+	b.Set(-1, -1)
+	ResetNodeAllocations()
+	b.Set(-1, -2)
+	buckets, _ := NodeAllocations()
+	if buckets != 0 {
+		t.Fail()
+	}
+}
+
+func TestVectorSetAllocations(t *testing.T) {
+	v := Vector{}.Resize(100000)
+
+	ResetNodeAllocations()
+	v.Set(4711, 4711)
+	_, nodes := NodeAllocations()
+	if nodes != uint64(v.depth) {
+		t.Fail()
+	}
+}
+
+func TestVectorFingerprintCaching(t *testing.T) {
+	var v Vector
+	for i := 0; i < 100; i++ {
+		v = v.Append(i)
+	}
+	computed := func() uint64 {
+		return atomic.LoadUint64(&fingerprints)
+	}
+	start := computed()
+
+	first := v.Fingerprint()
+	copied := v
+	if copied.Fingerprint() != first || computed() != start+1 {
+		t.Fail()
+	}
+
+	derived := v.Set(0, 1000)
+	derived.Fingerprint()
+	if computed() != start+2 {
+		t.Fail()
+	}
+
+	sliced := v.Slice(0, 50)
+	sliced.Fingerprint()
+	if computed() != start+3 {
+		t.Fail()
+	}
+	for i := 0; i < 10; i++ {
+		if v.Fingerprint() != first || sliced.Fingerprint() != sliced.Slice(0, 50).Fingerprint() {
+			t.Fail()
+		}
+	}
+	if computed() != start+3 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,78 @@
+package immutable
+
+import "reflect"
+
+// compositeKey chains the parts of a composite key. The tail is
+// either nil or another compositeKey, which keeps the whole chain
+// comparable using ==. Parts counts the parts from head to the end
+// of the chain, separating the empty key from a single nil part.
+type compositeKey struct {
+	head  interface{}
+	tail  interface{}
+	parts int
+}
+
+// CompositeKey returns a comparable map key made up of the given
+// parts. Two composite keys are equal if they have the same number
+// of parts, with pairwise equal parts.
+// Incomparable parts causes panic, including parts holding
+// incomparable values in interface fields or array elements.
+func CompositeKey(parts ...interface{}) interface{} {
+	var key interface{}
+	for i := len(parts) - 1; i >= 0; i-- {
+		part := parts[i]
+		if part != nil && !isComparable(reflect.ValueOf(part)) {
+			panic("Key must be comparable")
+		}
+		key = compositeKey{head: part, tail: key, parts: len(parts) - i}
+	}
+	if key == nil {
+		return compositeKey{}
+	}
+	return key
+}
+
+// isComparable returns true if value can be compared using ==
+// without panicking. Unlike Type.Comparable, the dynamic values of
+// interfaces nested in structs and arrays are checked.
+func isComparable(value reflect.Value) bool {
+	if !value.Type().Comparable() {
+		return false
+	}
+	switch value.Kind() {
+	case reflect.Interface:
+		return value.IsNil() || isComparable(value.Elem())
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if !isComparable(value.Field(i)) {
+				return false
+			}
+		}
+	case reflect.Array:
+		switch value.Type().Elem().Kind() {
+		case reflect.Interface, reflect.Struct, reflect.Array:
+			for i := 0; i < value.Len(); i++ {
+				if !isComparable(value.Index(i)) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// hash combines the hashes of all parts of the key.
+func (k compositeKey) hash() uint32 {
+	var bytes []uint8
+	var key interface{} = k
+	for key != nil && k.parts > 0 {
+		part := key.(compositeKey)
+		var hash uint32
+		if part.head != nil {
+			hash = hashValue(part.head)
+		}
+		bytes = append(bytes, uint8(hash), uint8(hash>>8), uint8(hash>>16), uint8(hash>>24))
+		key = part.tail
+	}
+	return hashFunc(bytes)
+}
@@ -0,0 +1,75 @@
+package immutable
+
+import "testing"
+
+func TestCompositeKey(t *testing.T) {
+	var m Map
+	for i := 0; i < 50; i++ {
+		for _, s := range []string{"a", "b", "c"} {
+			m = m.Set(CompositeKey(i, s), s)
+		}
+	}
+	if m.Size() != 150 {
+		t.Fail()
+	}
+	for i := 0; i < 50; i++ {
+		for _, s := range []string{"a", "b", "c"} {
+			if v, ok := m.Get(CompositeKey(i, s)); !ok || v != s {
+				t.Fail()
+			}
+		}
+	}
+	if m.Has(CompositeKey(1)) || m.Has(CompositeKey(1, "a", nil)) || m.Has(CompositeKey("a", 1)) {
+		t.Fail()
+	}
+}
+
+func TestCompositeKeyEquality(t *testing.T) {
+	if CompositeKey(1, "a") != CompositeKey(1, "a") {
+		t.Fail()
+	}
+	if CompositeKey(1, "a") == CompositeKey(1, "b") {
+		t.Fail()
+	}
+	if CompositeKey() != CompositeKey() || CompositeKey() == CompositeKey(nil) {
+		t.Fail()
+	}
+	if CompositeKey(CompositeKey(1, 2), 3) == CompositeKey(1, 2, 3) {
+		t.Fail()
+	}
+}
+
+func TestCompositeKeyIncomparablePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	CompositeKey(1, []int{1})
+}
+
+func TestCompositeKeyNestedIncomparablePanics(t *testing.T) {
+	type holder struct {
+		value interface{}
+	}
+	for _, part := range []interface{}{
+		holder{[]int{1}},
+		[2]interface{}{1, map[int]int{}},
+		holder{holder{func() {}}},
+		[1]holder{{[]byte("a")}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fail()
+				}
+			}()
+			CompositeKey(1, part)
+		}()
+	}
+
+	key := CompositeKey(holder{1}, [2]interface{}{"a", holder{nil}})
+	if !(Map{}).Set(key, 1).Has(CompositeKey(holder{1}, [2]interface{}{"a", holder{nil}})) {
+		t.Fail()
+	}
+}
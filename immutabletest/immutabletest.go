@@ -0,0 +1,44 @@
+// Package immutabletest provides helpers for testing code using
+// the immutable containers.
+package immutabletest
+
+import (
+	"github.com/erkkah/immutable"
+)
+
+// TB is the part of testing.TB used by the helpers.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertEqual reports an error to t unless the vectors have the
+// same size and == equal elements.
+// The first differing index and elements are reported.
+// Returns true if the vectors are equal.
+func AssertEqual(t TB, got, want immutable.Vector) bool {
+	t.Helper()
+
+	size := got.Size()
+	if want.Size() < size {
+		size = want.Size()
+	}
+
+	gotElements := got.Elements()
+	wantElements := want.Elements()
+	for index := uint32(0); index < size; index++ {
+		gotElements.Next()
+		wantElements.Next()
+		if gotElements.Get() != wantElements.Get() {
+			t.Errorf("vectors differ at index %d: got %v, want %v",
+				index, gotElements.Get(), wantElements.Get())
+			return false
+		}
+	}
+
+	if got.Size() != want.Size() {
+		t.Errorf("vector sizes differ: got %d, want %d", got.Size(), want.Size())
+		return false
+	}
+	return true
+}
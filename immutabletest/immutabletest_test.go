@@ -0,0 +1,61 @@
+package immutabletest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/erkkah/immutable"
+)
+
+type fakeTB struct {
+	errors []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func vectorOf(values ...interface{}) immutable.Vector {
+	var v immutable.Vector
+	for _, value := range values {
+		v = v.Append(value)
+	}
+	return v
+}
+
+func TestAssertEqualPasses(t *testing.T) {
+	var tb fakeTB
+	if !AssertEqual(&tb, vectorOf(1, 2, 3), vectorOf(1, 2, 3)) {
+		t.Fail()
+	}
+	if len(tb.errors) != 0 {
+		t.Fail()
+	}
+}
+
+func TestAssertEqualReportsFirstDifference(t *testing.T) {
+	var tb fakeTB
+	if AssertEqual(&tb, vectorOf(1, 2, 3, 4), vectorOf(1, 2, 5, 6)) {
+		t.Fail()
+	}
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "index 2") {
+		t.Fail()
+	}
+}
+
+func TestAssertEqualReportsSize(t *testing.T) {
+	var tb fakeTB
+	if AssertEqual(&tb, vectorOf(1, 2), vectorOf(1, 2, 3)) {
+		t.Fail()
+	}
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], "sizes") {
+		t.Fail()
+	}
+}
+
+func TestAssertEqualWithTestingT(t *testing.T) {
+	AssertEqual(t, vectorOf("a"), vectorOf("a"))
+}
@@ -0,0 +1,204 @@
+package immutable
+
+// List is an immutable singly linked list.
+// Modifying the list returns a new list instance, sharing as much
+// structure as possible with the original list.
+// Since the list is immutable, it is safe to use from multiple
+// concurrent threads without locks or other synchronization.
+//
+// Prepending, and accessing the head, are constant time operations.
+// Accessing or modifying an element by index requires walking the
+// list up to the element, and appending copies the whole list.
+//
+// The list keeps track of its size, so Size is a constant time
+// operation. Elements beyond the size are not part of the list,
+// which lets lists share their nodes with longer lists.
+//
+// The zero List is empty and ready for use.
+type List struct {
+	head *listNode
+	size int
+}
+
+type listNode struct {
+	value interface{}
+	next  *listNode
+}
+
+// Size returns the number of elements in the list.
+func (l List) Size() int {
+	return l.size
+}
+
+// Prepend returns a list with the value added to the front.
+// All elements of the original list are shared.
+func (l List) Prepend(value interface{}) List {
+	return List{
+		head: &listNode{value: value, next: l.head},
+		size: l.size + 1,
+	}
+}
+
+// Append returns a list with the value added to the end.
+// This copies all elements of the list.
+func (l List) Append(value interface{}) List {
+	head, last := l.copyPrefix(l.size)
+	node := &listNode{value: value}
+	if last == nil {
+		head = node
+	} else {
+		last.next = node
+	}
+	return List{head: head, size: l.size + 1}
+}
+
+// Head returns the first element of the list.
+// Accessing the head of an empty list causes panic.
+func (l List) Head() interface{} {
+	if l.size == 0 {
+		panic("Empty list access")
+	}
+	return l.head.value
+}
+
+// Tail returns the list without its first element, sharing all
+// remaining elements.
+// Accessing the tail of an empty list causes panic.
+func (l List) Tail() List {
+	if l.size == 0 {
+		panic("Empty list access")
+	}
+	return List{head: l.head.next, size: l.size - 1}
+}
+
+// Get returns the element at the given index.
+// Out of bounds access causes panic.
+func (l List) Get(index int) interface{} {
+	if index < 0 || index >= l.size {
+		panic("Out of bounds list access")
+	}
+	return l.node(index).value
+}
+
+// Set returns a list with the element at the given index replaced.
+// Elements before the index are copied, the rest are shared.
+// Out of bounds access causes panic.
+func (l List) Set(index int, value interface{}) List {
+	if index < 0 || index >= l.size {
+		panic("Out of bounds list access")
+	}
+	head, last := l.copyPrefix(index)
+	node := &listNode{value: value, next: l.node(index).next}
+	if last == nil {
+		head = node
+	} else {
+		last.next = node
+	}
+	return List{head: head, size: l.size}
+}
+
+// Resize returns a list with the given size.
+// Shrinking shares the remaining elements, growing copies all
+// elements and adds nil elements to the end.
+// Negative sizes causes panic.
+func (l List) Resize(size int) List {
+	if size < 0 {
+		panic("Invalid list size")
+	}
+	if size <= l.size {
+		return List{head: l.head, size: size}
+	}
+
+	head, last := l.copyPrefix(l.size)
+	for i := l.size; i < size; i++ {
+		node := &listNode{}
+		if last == nil {
+			head = node
+		} else {
+			last.next = node
+		}
+		last = node
+	}
+	return List{head: head, size: size}
+}
+
+// Slice returns a slice of a list for the specified range.
+// Ranges that extend the list end returns a slice shorter than the
+// given range, just like for Vector.Slice.
+// Invalid ranges, and negative indices, causes panic.
+//
+// All elements of the slice are shared with the original list, and
+// finding the start of the slice requires walking start elements.
+func (l List) Slice(start, end int) List {
+	if start < 0 || end < start {
+		panic("Invalid range")
+	}
+	if end == start || start >= l.size {
+		return List{}
+	}
+	if end > l.size {
+		end = l.size
+	}
+	return List{head: l.node(start), size: end - start}
+}
+
+// Range calls visitor for each element of the list, in order.
+// If visitor returns false, the iteration stops.
+func (l List) Range(visitor func(index int, value interface{}) bool) {
+	node := l.head
+	for i := 0; i < l.size; i++ {
+		if !visitor(i, node.value) {
+			return
+		}
+		node = node.next
+	}
+}
+
+// ToVector returns a vector with the elements of the list, in order.
+func (l List) ToVector() Vector {
+	builder := NewVectorBuilder(uint32(l.size))
+	l.Range(func(index int, value interface{}) bool {
+		builder.Append(value)
+		return true
+	})
+	return builder.Build()
+}
+
+// VectorToList returns a list with the elements of the vector,
+// in order.
+func VectorToList(v Vector) List {
+	values := v.values()
+	var l List
+	for i := len(values) - 1; i >= 0; i-- {
+		l = l.Prepend(values[i])
+	}
+	return l
+}
+
+// node returns the node at the given index, which must be within
+// the list.
+func (l List) node(index int) *listNode {
+	node := l.head
+	for i := 0; i < index; i++ {
+		node = node.next
+	}
+	return node
+}
+
+// copyPrefix copies the first count nodes of the list, returning
+// the first and last copied nodes. The last node links to the rest
+// of the original list.
+func (l List) copyPrefix(count int) (head, last *listNode) {
+	node := l.head
+	for i := 0; i < count; i++ {
+		copied := &listNode{value: node.value, next: node.next}
+		if last == nil {
+			head = copied
+		} else {
+			last.next = copied
+		}
+		last = copied
+		node = node.next
+	}
+	return head, last
+}
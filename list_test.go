@@ -0,0 +1,397 @@
+package immutable
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestListSize(t *testing.T) {
+	var l List
+	if l.Size() != 0 {
+		t.Fail()
+	}
+	for i := 0; i < 100; i++ {
+		l = l.Append(i)
+	}
+	if l.Size() != 100 {
+		t.Fail()
+	}
+	if l.Prepend(-1).Size() != 101 || l.Tail().Size() != 99 {
+		t.Fail()
+	}
+	if l.Set(50, "changed").Size() != 100 {
+		t.Fail()
+	}
+	if l.Resize(10).Size() != 10 || l.Resize(150).Size() != 150 || l.Resize(0).Size() != 0 {
+		t.Fail()
+	}
+	if l.Resize(10).Append(1).Size() != 11 {
+		t.Fail()
+	}
+	if l.Slice(10, 60).Size() != 50 || l.Slice(90, 200).Size() != 10 {
+		t.Fail()
+	}
+}
+
+func TestListAppendAndGet(t *testing.T) {
+	var l List
+	for i := 0; i < 100; i++ {
+		l = l.Append(i)
+	}
+	for i := 0; i < 100; i++ {
+		if l.Get(i) != i {
+			t.Fail()
+		}
+	}
+	if l.Head() != 0 || l.Tail().Head() != 1 {
+		t.Fail()
+	}
+}
+
+func TestListPrependSharesElements(t *testing.T) {
+	l := List{}.Prepend(2).Prepend(1)
+	prepended := l.Prepend(0)
+	if prepended.Tail().head != l.head {
+		t.Fail()
+	}
+	for i := 0; i < 3; i++ {
+		if prepended.Get(i) != i {
+			t.Fail()
+		}
+	}
+	if l.Size() != 2 || l.Head() != 1 {
+		t.Fail()
+	}
+}
+
+func TestListSet(t *testing.T) {
+	var l List
+	for i := 0; i < 10; i++ {
+		l = l.Append(i)
+	}
+	changed := l.Set(5, "five")
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			if changed.Get(i) != "five" || l.Get(i) != 5 {
+				t.Fail()
+			}
+		} else if changed.Get(i) != i {
+			t.Fail()
+		}
+	}
+	if changed.node(6) != l.node(6) {
+		t.Fail()
+	}
+}
+
+func TestListResize(t *testing.T) {
+	l := List{}.Append(1).Append(2).Append(3)
+	shrunk := l.Resize(2)
+	grown := shrunk.Resize(4)
+	if grown.Get(0) != 1 || grown.Get(1) != 2 || grown.Get(2) != nil || grown.Get(3) != nil {
+		t.Fail()
+	}
+	if l.Get(2) != 3 {
+		t.Fail()
+	}
+	visited := 0
+	shrunk.Range(func(index int, value interface{}) bool {
+		visited++
+		return true
+	})
+	if visited != 2 {
+		t.Fail()
+	}
+}
+
+func TestListTailSlice(t *testing.T) {
+	var l List
+	for i := 0; i < 10; i++ {
+		l = l.Append(i)
+	}
+	tail := l.Slice(3, l.Size())
+	if tail.Size() != 7 || tail.head != l.node(3) {
+		t.Fail()
+	}
+	for i := 0; i < tail.Size(); i++ {
+		if tail.Get(i) != i+3 {
+			t.Fail()
+		}
+	}
+	if l.Slice(0, 10).head != l.head || l.Slice(0, 10).Size() != 10 {
+		t.Fail()
+	}
+}
+
+func TestListBoundedSlice(t *testing.T) {
+	var l List
+	for i := 0; i < 10; i++ {
+		l = l.Append(i)
+	}
+	s := l.Slice(2, 5)
+	if s.Size() != 3 || s.Get(0) != 2 || s.Get(2) != 4 {
+		t.Fail()
+	}
+	appended := s.Append("end")
+	if appended.Size() != 4 || appended.Get(3) != "end" || l.Get(5) != 5 {
+		t.Fail()
+	}
+	if l.Slice(8, 100).Size() != 2 || l.Slice(20, 30).Size() != 0 || l.Slice(4, 4).Size() != 0 {
+		t.Fail()
+	}
+	if s.Slice(1, 2).Get(0) != 3 {
+		t.Fail()
+	}
+}
+
+func TestListInvalidSliceFails(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	List{}.Append(1).Slice(1, 0)
+}
+
+func TestListRangeStops(t *testing.T) {
+	var l List
+	for i := 0; i < 10; i++ {
+		l = l.Append(i)
+	}
+	visited := 0
+	l.Range(func(index int, value interface{}) bool {
+		if index != visited || value != index {
+			t.Fail()
+		}
+		visited++
+		return visited < 5
+	})
+	if visited != 5 {
+		t.Fail()
+	}
+}
+
+func TestListVectorRoundTrip(t *testing.T) {
+	var l List
+	for i := 0; i < 100; i++ {
+		l = l.Append(i)
+	}
+	l = l.Set(10, nil)
+
+	v := l.ToVector()
+	if v.Size() != 100 {
+		t.Fail()
+	}
+	back := VectorToList(v)
+	if back.Size() != l.Size() {
+		t.Fail()
+	}
+	for i := 0; i < l.Size(); i++ {
+		if v.Get(uint32(i)) != l.Get(i) || back.Get(i) != l.Get(i) {
+			t.Fail()
+		}
+	}
+
+	sliced := VectorToList(v.Slice(90, 100))
+	if sliced.Size() != 10 || sliced.Head() != 90 {
+		t.Fail()
+	}
+	if (List{}).ToVector().Size() != 0 || VectorToList(Vector{}).Size() != 0 {
+		t.Fail()
+	}
+}
+
+func TestListOutOfBoundsFails(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	List{}.Append(1).Resize(0).Get(0)
+}
+
+func TestListEmptyHeadFails(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	List{}.Head()
+}
+
+const (
+	listValues       = 1024
+	listAppendValues = 128
+)
+
+func BenchmarkPrependImmutableList(b *testing.B) {
+	var v atomic.Value
+	v.Store(List{})
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for j := 0; j < listValues; j++ {
+				old := v.Load().(List)
+				if j == 0 {
+					old = List{}
+				}
+				v.Store(old.Prepend(j))
+			}
+		}
+	})
+}
+
+func BenchmarkPrependContainerList(b *testing.B) {
+	l := list.New()
+	var mutex sync.Mutex
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for j := 0; j < listValues; j++ {
+				mutex.Lock()
+				if j == 0 {
+					l.Init()
+				}
+				l.PushFront(j)
+				mutex.Unlock()
+			}
+		}
+	})
+}
+
+func BenchmarkPrependGoSlice(b *testing.B) {
+	a := []int{}
+	var mutex sync.Mutex
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for j := 0; j < listValues; j++ {
+				mutex.Lock()
+				if j == 0 {
+					a = []int{}
+				}
+				a = append([]int{j}, a...)
+				mutex.Unlock()
+			}
+		}
+	})
+}
+
+func BenchmarkAppendImmutableList(b *testing.B) {
+	var v atomic.Value
+	v.Store(List{})
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for j := 0; j < listAppendValues; j++ {
+				old := v.Load().(List)
+				if j == 0 {
+					old = List{}
+				}
+				v.Store(old.Append(j))
+			}
+		}
+	})
+}
+
+func BenchmarkAppendContainerList(b *testing.B) {
+	l := list.New()
+	var mutex sync.Mutex
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for j := 0; j < listAppendValues; j++ {
+				mutex.Lock()
+				if j == 0 {
+					l.Init()
+				}
+				l.PushBack(j)
+				mutex.Unlock()
+			}
+		}
+	})
+}
+
+func BenchmarkAppendListGoSlice(b *testing.B) {
+	a := []int{}
+	var mutex sync.Mutex
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for j := 0; j < listAppendValues; j++ {
+				mutex.Lock()
+				if j == 0 {
+					a = []int{}
+				}
+				a = append(a, j)
+				mutex.Unlock()
+			}
+		}
+	})
+}
+
+func BenchmarkHeadTailImmutableList(b *testing.B) {
+	var l List
+	for j := 0; j < listValues; j++ {
+		l = l.Prepend(j)
+	}
+	var v atomic.Value
+	v.Store(l)
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			sum := 0
+			for l := v.Load().(List); l.Size() > 0; l = l.Tail() {
+				sum += l.Head().(int)
+			}
+		}
+	})
+}
+
+func BenchmarkHeadTailContainerList(b *testing.B) {
+	l := list.New()
+	for j := 0; j < listValues; j++ {
+		l.PushFront(j)
+	}
+	var mutex sync.Mutex
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			sum := 0
+			mutex.Lock()
+			for e := l.Front(); e != nil; e = e.Next() {
+				sum += e.Value.(int)
+			}
+			mutex.Unlock()
+		}
+	})
+}
+
+func BenchmarkHeadTailGoSlice(b *testing.B) {
+	a := make([]int, listValues)
+	for j := range a {
+		a[j] = j
+	}
+	var v atomic.Value
+	v.Store(a)
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			sum := 0
+			for a := v.Load().([]int); len(a) > 0; a = a[1:] {
+				sum += a[0]
+			}
+		}
+	})
+}
@@ -1,10 +1,21 @@
 package immutable
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
 	"reflect"
+	"sort"
+	"strings"
 	"unsafe"
 )
 
+// contextCheckInterval is the number of map entries visited between
+// checks for context cancellation.
+const contextCheckInterval = 64
+
 const (
 	bucketCount    uint32 = 8
 	levels         uint32 = 4
@@ -23,74 +34,186 @@ const (
 // Map is different from Go map and sync.Map since it safe to
 // copy and is copied by value.
 //
+// Keys must be comparable, except for []byte keys, which are
+// compared by content. The map stores a copy of []byte keys, which
+// must not be modified when visited by Range.
+//
 // The zero Map is empty and ready for use.
 type Map struct {
 	leafCount uint32
 	capacity  uint32
 	size      uint32
 	root      bucket
+	config    *mapConfig
+}
+
+// defaultLoadFactor is the load factor of maps not configured
+// using WithLoadFactor.
+const defaultLoadFactor = 0.5
+
+// mapConfig holds map tuning set up by NewMap
+// and shared by all maps derived from it.
+type mapConfig struct {
+	loadFactor float64
+	hasher     func(key interface{}) uint32
+	normalizer func(key interface{}) interface{}
+}
+
+// MapOption configures a map created by NewMap.
+type MapOption func(config *mapConfig)
+
+// NewMap returns an empty map configured by the given options.
+func NewMap(options ...MapOption) Map {
+	config := &mapConfig{
+		loadFactor: defaultLoadFactor,
+	}
+	for _, option := range options {
+		option(config)
+	}
+	return Map{
+		config: config,
+	}
+}
+
+// WithLoadFactor sets the ratio between size and capacity
+// at which the map grows its capacity.
+// Lower load factors use more memory, but causes fewer collisions.
+// The load factor must be larger than zero.
+func WithLoadFactor(loadFactor float64) MapOption {
+	if loadFactor <= 0 {
+		panic("Invalid load factor")
+	}
+	return func(config *mapConfig) {
+		config.loadFactor = loadFactor
+	}
+}
+
+// WithKeyNormalizer sets a function that is applied to all keys
+// before they are used by Set, Get and Delete.
+// The map stores the normalized keys, and those are the keys
+// visited by Range.
+// For example, using strings.ToLower gives a map with case
+// insensitive string keys.
+//
+// The normalizer must be idempotent, returning normalized keys
+// unchanged. Operations that copy entries into a map with the same
+// configuration, such as Compact, Filter, MapValues and KeySet,
+// normalize the stored keys again.
+func WithKeyNormalizer(normalizer func(key interface{}) interface{}) MapOption {
+	return func(config *mapConfig) {
+		config.normalizer = normalizer
+	}
+}
+
+// NewMapWithHasher returns an empty map using hasher to hash keys,
+// instead of the default key hashing.
+// Keys that are equal must have equal hashes.
+func NewMapWithHasher(hasher func(key interface{}) uint32, options ...MapOption) Map {
+	return NewMap(append(options, withHasher(hasher))...)
+}
+
+func withHasher(hasher func(key interface{}) uint32) MapOption {
+	return func(config *mapConfig) {
+		config.hasher = hasher
+	}
 }
 
 // Set adds an entry to a map and returns the updated map.
 func (m Map) Set(key, value interface{}) Map {
-	hash := hashValue(key)
+	return m.set(key, value, nil)
+}
+
+// set adds an entry to the map, modifying buckets that belong to
+// owner in place and copying all others.
+func (m Map) set(key, value interface{}, owner *owner) Map {
+	key = m.normalize(key)
+	m, _ = m.store(key, m.hash(key), value, nil, false, owner)
+	return m
+}
+
+// Update stores the value returned by compute for key. Compute is
+// called with the current value of the entry, and whether the entry
+// existed. The map is walked once, just as for Set.
+func (m Map) Update(key interface{}, compute func(old interface{}, existed bool) interface{}) Map {
+	key = m.normalize(key)
+	m, _ = m.store(key, m.hash(key), nil, compute, false, nil)
+	return m
+}
+
+// SetIfAbsent adds an entry to the map only if there is no entry for
+// the key. Returns the updated map and true if the entry was added,
+// or the unchanged map and false if the key was already present.
+// The map is walked once, and no buckets are copied when the key is
+// present.
+func (m Map) SetIfAbsent(key, value interface{}) (Map, bool) {
+	key = m.normalize(key)
+	return m.store(key, m.hash(key), value, nil, true, nil)
+}
 
+// store adds an entry with a normalized key and its hash to the map.
+// If compute is not nil, the stored value is computed from the
+// current entry instead.
+// If ifAbsent is set and the key is present, the unchanged map and
+// false are returned.
+//
+// The path to the leaf bucket is recorded while walking down, and
+// only copied by storePath once it is known that the map changes.
+func (m Map) store(key interface{}, hash uint32, value interface{}, compute func(old interface{}, existed bool) interface{}, ifAbsent bool, owner *owner) (Map, bool) {
+	original := m
 	if m.capacity == 0 {
 		m.leafCount = leafStartCount
 		m.capacity = mapCapacity(m.leafCount)
-	} else if m.size*2 >= m.capacity {
+	} else if m.size >= m.growThreshold() {
 		m.leafCount *= 2
 		m.capacity *= 2
 	}
 
-	b := &m.root
+	var path [levels]*bucket
+	var indices [levels]uint32
+	b := m.root.buckets[hash%bucketCount]
 
 	for level := uint32(0); level < levels; level++ {
-		bucketIndex := hash % bucketCount
-
-		next := b.buckets[bucketIndex]
-		if next == nil {
-			next = &bucket{}
-		} else {
-			next = &bucket{
-				next.buckets,
-				next.values,
-			}
+		indices[level] = hash % bucketCount
+		if level > 0 && b != nil {
+			b = b.buckets[indices[level]]
 		}
-		b.buckets[bucketIndex] = next
-
+		path[level] = b
 		hash /= bucketCount
-		b = next
 	}
 
-	newValues := make([]elementList, m.leafCount)
+	if ifAbsent && b != nil {
+		if _, exists := b.find(key, hash); exists {
+			return original, false
+		}
+	}
 
-	if uint32(len(b.values)) != m.leafCount {
-		for _, list := range b.values {
-			for _, element := range list {
-				hash := hashValue(element.key)
-				for l := uint32(0); l < levels; l++ {
-					hash /= bucketCount
-				}
+	return m.storePath(&path, &indices, key, hash, value, compute, owner), true
+}
 
-				valueIndex := hash % m.leafCount
-				newList := newValues[valueIndex]
-				newList = append(newList, element)
-				newValues[valueIndex] = newList
-			}
-		}
-	} else {
-		copy(newValues, b.values)
+// storePath adds an entry to the map, copying the recorded path of
+// buckets leading to its leaf bucket. Hash is the part of the key
+// hash remaining after walking the bucket levels.
+func (m Map) storePath(path *[levels]*bucket, indices *[levels]uint32, key interface{}, hash uint32, value interface{}, compute func(old interface{}, existed bool) interface{}, owner *owner) Map {
+	b := &m.root
+	for level, node := range path {
+		next := node.editable(owner)
+		b.buckets[indices[level]] = next
+		b = next
 	}
 
-	b.values = newValues
+	if uint32(len(b.values)) != m.leafCount {
+		m.rehash(b)
+	}
 
 	valueIndex := hash % m.leafCount
 	list := b.values[valueIndex]
 	list = append(list[:0:0], list...)
 
 	for i, e := range list {
-		if e.key == key {
+		if keysEqual(e.key, key) {
+			if compute != nil {
+				value = compute(e.value, true)
+			}
 			e.value = value
 			list[i] = e
 			b.values[valueIndex] = list
@@ -98,19 +221,70 @@ func (m Map) Set(key, value interface{}) Map {
 		}
 	}
 
+	if compute != nil {
+		value = compute(nil, false)
+	}
+	if data, ok := key.([]byte); ok {
+		key = append([]byte(nil), data...)
+	}
 	list = append(list, element{key, value})
 	b.values[valueIndex] = list
 	m.size++
 	return m
 }
 
+// editable returns the bucket itself if it belongs to owner,
+// otherwise a copy belonging to owner.
+// A nil bucket results in a new, empty bucket.
+func (b *bucket) editable(owner *owner) *bucket {
+	if owner != nil && b != nil && b.owner == owner {
+		return b
+	}
+	countBucketAlloc()
+	if b == nil {
+		return &bucket{owner: owner}
+	}
+	return &bucket{
+		buckets: b.buckets,
+		values:  append([]elementList(nil), b.values...),
+		owner:   owner,
+	}
+}
+
 // Get retrieves a value from the map.
 func (m Map) Get(key interface{}) (interface{}, bool) {
+	return m.find(m.normalize(key))
+}
+
+// GetOrDefault retrieves a value from the map, or returns fallback
+// if there is no entry for the key.
+func (m Map) GetOrDefault(key, fallback interface{}) interface{} {
+	if value, ok := m.Get(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// Has returns true if the map has an entry for the key.
+func (m Map) Has(key interface{}) bool {
+	_, ok := m.Get(key)
+	return ok
+}
+
+// find retrieves a value from the map by normalized key.
+func (m Map) find(key interface{}) (interface{}, bool) {
 	if m.capacity == 0 {
 		return nil, false
 	}
+	return m.lookup(key, m.hash(key))
+}
 
-	hash := hashValue(key)
+// lookup retrieves a value from the map by normalized key and
+// its hash.
+func (m Map) lookup(key interface{}, hash uint32) (interface{}, bool) {
+	if m.capacity == 0 {
+		return nil, false
+	}
 
 	b := &m.root
 	for level := uint32(0); level < levels; level++ {
@@ -123,6 +297,12 @@ func (m Map) Get(key interface{}) (interface{}, bool) {
 		hash /= bucketCount
 	}
 
+	return b.find(key, hash)
+}
+
+// find retrieves a value from a leaf bucket by normalized key and
+// the part of its hash remaining after walking the bucket levels.
+func (b *bucket) find(key interface{}, hash uint32) (interface{}, bool) {
 	if len(b.values) == 0 {
 		return nil, false
 	}
@@ -131,7 +311,7 @@ func (m Map) Get(key interface{}) (interface{}, bool) {
 	list := b.values[valueIndex]
 
 	for _, e := range list {
-		if e.key == key {
+		if keysEqual(e.key, key) {
 			return e.value, true
 		}
 	}
@@ -139,71 +319,637 @@ func (m Map) Get(key interface{}) (interface{}, bool) {
 	return nil, false
 }
 
+// GetOrCompute returns the value stored for key if present, together
+// with the unchanged map. Otherwise, compute is called to create the
+// value, which is then inserted and returned with the updated map.
+//
+// onEvict is accepted for callers that switch between a Map and
+// bounded caches that evict values. A persistent Map never evicts
+// values, so onEvict is never called and may be nil.
+func (m Map) GetOrCompute(key interface{}, compute func() interface{}, onEvict func(old interface{})) (Map, interface{}) {
+	if value, ok := m.Get(key); ok {
+		return m, value
+	}
+	value := compute()
+	return m.Set(key, value), value
+}
+
 // Delete returns a map without entries matching the key.
 // If no entry matches, the original map is returned.
+// The capacity of the map shrinks as it empties, mirroring growth
+// in Set.
 func (m Map) Delete(key interface{}) Map {
-	if m.capacity == 0 {
+	return m.delete(key, nil)
+}
+
+// DeleteAll returns a map without entries matching any of the keys.
+// Keys without entries are skipped. Storage is copied once for all
+// deletions, instead of once per key.
+func (m Map) DeleteAll(keys ...interface{}) Map {
+	builder := m.Builder()
+	for _, key := range keys {
+		builder.Delete(key)
+	}
+	return builder.Build()
+}
+
+// delete removes an entry from the map, modifying buckets that
+// belong to owner in place and copying all others.
+func (m Map) delete(key interface{}, owner *owner) Map {
+	key = m.normalize(key)
+	if _, exists := m.find(key); !exists {
 		return m
 	}
 
-	hash := hashValue(key)
+	hash := m.hash(key)
 
-	root := m.root
-	b := &root
+	b := &m.root
 
 	for level := uint32(0); level < levels; level++ {
 		bucketIndex := hash % bucketCount
 
-		next := b.buckets[bucketIndex]
-		if next == nil {
-			return m
-		}
-		next = &bucket{
-			next.buckets,
-			next.values,
-		}
+		next := b.buckets[bucketIndex].editable(owner)
 		b.buckets[bucketIndex] = next
 
 		hash /= bucketCount
 		b = next
 	}
 
-	if len(b.values) == 0 {
-		return m
-	}
-	newValues := make([]elementList, m.leafCount)
-	copy(newValues, b.values)
-	b.values = newValues
-
 	valueIndex := hash % uint32(len(b.values))
 	list := b.values[valueIndex]
 	list = append(elementList{}, list...)
 
 	for i, e := range list {
-		if e.key == key {
+		if keysEqual(e.key, key) {
 			list = append(list[0:i], list[i+1:]...)
-			b.values[valueIndex] = list
-			return Map{
-				size: m.size - 1,
-				root: root,
-			}
+			break
 		}
 	}
+	b.values[valueIndex] = list
+	m.size--
+
+	if m.leafCount > leafStartCount && m.size < m.shrinkThreshold() {
+		m.leafCount /= 2
+		m.capacity /= 2
+	}
+	if uint32(len(b.values)) != m.leafCount {
+		m.rehash(b)
+	}
 	return m
 }
 
+// rehash redistributes the entries of a leaf bucket over the current
+// leaf count of the map.
+func (m Map) rehash(b *bucket) {
+	newValues := make([]elementList, m.leafCount)
+	for _, list := range b.values {
+		for _, element := range list {
+			hash := m.hash(element.key)
+			for l := uint32(0); l < levels; l++ {
+				hash /= bucketCount
+			}
+
+			valueIndex := hash % m.leafCount
+			newList := newValues[valueIndex]
+			newList = append(newList, element)
+			newValues[valueIndex] = newList
+		}
+	}
+	b.values = newValues
+}
+
+// Compact returns a map with the same entries and configuration,
+// with storage sized for the current number of entries.
+// Delete shrinks the capacity as the map empties, but only resizes
+// the storage it touches. Compact rebuilds all storage, releasing
+// storage kept after deleting many entries.
+func (m Map) Compact() Map {
+	builder := m.empty().Builder()
+	m.Range(func(key, value interface{}) bool {
+		builder.Set(key, value)
+		return true
+	})
+	return builder.Build()
+}
+
 // Range calls visitor for each element in the map.
 // If visitor returns false, the iteration stops.
 // Since the map is immutable, it will not change during iteration.
-func (m *Map) Range(visitor func(key, value interface{}) bool) {
+//
+// The iteration order is unspecified, but stable for a map value:
+// ranging over the same map, or a copy of it, always visits the
+// elements in the same order.
+func (m Map) Range(visitor func(key, value interface{}) bool) {
 	m.root.visit(visitor)
 }
 
+// RangeLimit works like Range, but visits at most limit elements.
+func (m Map) RangeLimit(limit uint32, visitor func(key, value interface{}) bool) {
+	if limit == 0 {
+		return
+	}
+	visited := uint32(0)
+	m.Range(func(key, value interface{}) bool {
+		visited++
+		return visitor(key, value) && visited < limit
+	})
+}
+
+// RangeContext works like Range, but stops early if ctx is done,
+// returning the context error. The context is checked periodically,
+// so a few more entries may be visited after cancellation.
+func (m Map) RangeContext(ctx context.Context, visitor func(key, value interface{}) bool) error {
+	visited := 0
+	var err error
+	m.root.visit(func(key, value interface{}) bool {
+		if visited%contextCheckInterval == 0 {
+			if err = ctx.Err(); err != nil {
+				return false
+			}
+		}
+		visited++
+		return visitor(key, value)
+	})
+	return err
+}
+
+// RangeErr calls visitor for each element in the map, stopping at
+// the first error returned by visitor, which is then returned.
+func (m Map) RangeErr(visitor func(key, value interface{}) error) error {
+	var err error
+	m.root.visit(func(key, value interface{}) bool {
+		err = visitor(key, value)
+		return err == nil
+	})
+	return err
+}
+
+// SafeRange works like Range, but recovers from panics in visitor.
+// A panic stops the iteration, and is returned as an error.
+func (m Map) SafeRange(visitor func(key, value interface{}) bool) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("immutable: panic in map visitor: %v", recovered)
+		}
+	}()
+	m.Range(visitor)
+	return nil
+}
+
+// Accumulate folds the map entries into a single value, by calling fn
+// with the accumulated value and each entry, starting with initial.
+// If fn returns false, the iteration stops.
+// Entries are visited in unspecified order.
+func (m Map) Accumulate(initial interface{}, fn func(acc, key, value interface{}) (newAcc interface{}, keepGoing bool)) interface{} {
+	acc := initial
+	m.Range(func(key, value interface{}) bool {
+		var keepGoing bool
+		acc, keepGoing = fn(acc, key, value)
+		return keepGoing
+	})
+	return acc
+}
+
+// FoldSorted folds the map entries into a single value, by calling fn
+// with the accumulated value and each entry, starting with initial.
+// Entries are visited in the key order given by less, which gives a
+// deterministic result also for folds that depend on the order.
+// All entries are collected and sorted before folding.
+func (m Map) FoldSorted(less func(a, b interface{}) bool, initial interface{}, fn func(acc, key, value interface{}) interface{}) interface{} {
+	entries := m.entries()
+	sort.Slice(entries, func(i, j int) bool {
+		return less(entries[i].Key, entries[j].Key)
+	})
+	acc := initial
+	for _, e := range entries {
+		acc = fn(acc, e.Key, e.Value)
+	}
+	return acc
+}
+
+// RangeByValue calls visitor for each element in the map, in the
+// value order given by less.
+// If visitor returns false, the iteration stops.
+// All entries are collected and sorted before visiting.
+func (m Map) RangeByValue(less func(a, b interface{}) bool, visitor func(key, value interface{}) bool) {
+	entries := m.entries()
+	sort.Slice(entries, func(i, j int) bool {
+		return less(entries[i].Value, entries[j].Value)
+	})
+	for _, e := range entries {
+		if !visitor(e.Key, e.Value) {
+			return
+		}
+	}
+}
+
+// TopN returns the n entries with the largest values according
+// to less, largest first.
+func (m Map) TopN(n uint32, less func(a, b interface{}) bool) []MapEntry {
+	top := newTopN(n, func(a, b interface{}) bool {
+		return less(a.(MapEntry).Value, b.(MapEntry).Value)
+	})
+	m.Range(func(key, value interface{}) bool {
+		top.add(MapEntry{key, value})
+		return true
+	})
+
+	sorted := top.sorted()
+	entries := make([]MapEntry, len(sorted))
+	for i, e := range sorted {
+		entries[i] = e.(MapEntry)
+	}
+	return entries
+}
+
 // Size returns the number of elements in the map.
-func (m *Map) Size() uint32 {
+func (m Map) Size() uint32 {
 	return m.size
 }
 
+// Capacity returns the current capacity of the map.
+// The capacity grows when the size reaches a level given by
+// the load factor.
+func (m Map) Capacity() uint32 {
+	return m.capacity
+}
+
+// LoadFactor returns the load factor of the map.
+func (m Map) LoadFactor() float64 {
+	if m.config == nil {
+		return defaultLoadFactor
+	}
+	return m.config.loadFactor
+}
+
+func (m Map) growThreshold() uint32 {
+	if m.config == nil {
+		return m.capacity / 2
+	}
+	return uint32(float64(m.capacity) * m.config.loadFactor)
+}
+
+// sizedFor returns the map with capacity grown so that adding
+// expected entries does not grow it further.
+func (m Map) sizedFor(expected uint32) Map {
+	if m.capacity == 0 {
+		m.leafCount = leafStartCount
+		m.capacity = mapCapacity(m.leafCount)
+	}
+	for m.growThreshold() < expected && m.capacity <= math.MaxUint32/2 {
+		m.leafCount *= 2
+		m.capacity *= 2
+	}
+	return m
+}
+
+// shrinkThreshold is the size below which Delete halves the capacity.
+// It is a quarter of the growth threshold, so that a map shrinking
+// and growing around the same size does not rehash repeatedly.
+func (m Map) shrinkThreshold() uint32 {
+	return m.growThreshold() / 4
+}
+
+// empty returns an empty map with the same configuration.
+func (m Map) empty() Map {
+	return Map{
+		config: m.config,
+	}
+}
+
+// MergeWith returns a map with the entries of both maps, calling
+// resolve with the key and both values for keys present in both.
+// The result has the configuration of m.
+func (m Map) MergeWith(other Map, resolve func(key, a, b interface{}) interface{}) Map {
+	return MergeMapsWith(resolve, m, other)
+}
+
+// MergeMapsWith returns a map with the entries of all given maps,
+// folding them from left to right. For keys present in several maps,
+// resolve is called with the key, the value folded so far and the
+// value of the next map holding the key.
+// All entries are added using a single builder, and the result has
+// the configuration of the first map.
+func MergeMapsWith(resolve func(key, a, b interface{}) interface{}, maps ...Map) Map {
+	if len(maps) == 0 {
+		return Map{}
+	}
+
+	builder := maps[0].Builder()
+	for _, m := range maps[1:] {
+		m.Range(func(key, value interface{}) bool {
+			if existing, ok := builder.Get(key); ok {
+				value = resolve(key, existing, value)
+			}
+			builder.Set(key, value)
+			return true
+		})
+	}
+	return builder.Build()
+}
+
+// SetAll returns a map with all the given entries added, storing
+// them in a single working copy instead of copying for each entry.
+// Keys that are equal after normalization replace each other, in
+// the unspecified iteration order of pairs. Use SetEntries when the
+// order matters.
+func (m Map) SetAll(pairs map[interface{}]interface{}) Map {
+	builder := m.Builder()
+	for key, value := range pairs {
+		builder.Set(key, value)
+	}
+	return builder.Build()
+}
+
+// SetEntries returns a map with all the given entries added in order,
+// storing them in a single working copy like SetAll.
+// Later entries replace earlier entries with keys that are equal
+// after normalization.
+func (m Map) SetEntries(entries ...MapEntry) Map {
+	builder := m.Builder()
+	for _, e := range entries {
+		builder.Set(e.Key, e.Value)
+	}
+	return builder.Build()
+}
+
+// Merge returns a map with the entries of both maps.
+// Entries from other replace conflicting entries of m.
+// The result has the configuration of m. If both maps share the same
+// configuration, the entries of the smaller map are added to the
+// larger map.
+func (m Map) Merge(other Map) Map {
+	if m.size < other.size && m.config == other.config {
+		builder := other.Builder()
+		m.Range(func(key, value interface{}) bool {
+			if !other.Has(key) {
+				builder.Set(key, value)
+			}
+			return true
+		})
+		return builder.Build()
+	}
+
+	builder := m.Builder()
+	other.Range(func(key, value interface{}) bool {
+		builder.Set(key, value)
+		return true
+	})
+	return builder.Build()
+}
+
+// Filter returns a map with the entries for which predicate
+// returns true.
+func (m Map) Filter(predicate func(key, value interface{}) bool) Map {
+	builder := m.empty().Builder()
+	m.Range(func(key, value interface{}) bool {
+		if predicate(key, value) {
+			builder.Set(key, value)
+		}
+		return true
+	})
+	return builder.Build()
+}
+
+// OverlayDelta returns a map with the entries of m that are missing
+// from base or have different values in base, using == to compare
+// values.
+// Merging the result onto base gives a map with the entries of m,
+// in addition to the entries only present in base.
+func (m Map) OverlayDelta(base Map) Map {
+	builder := m.empty().Builder()
+	m.Range(func(key, value interface{}) bool {
+		if baseValue, ok := base.Get(key); !ok || baseValue != value {
+			builder.Set(key, value)
+		}
+		return true
+	})
+	return builder.Build()
+}
+
+// MapValues returns a map with the same keys, and values replaced
+// by the result of calling transform for each entry.
+func (m Map) MapValues(transform func(key, value interface{}) interface{}) Map {
+	result := m.empty()
+	m.Range(func(key, value interface{}) bool {
+		result = result.Set(key, transform(key, value))
+		return true
+	})
+	return result
+}
+
+// MergeReport returns a map with the entries of both maps, and the
+// number of conflicts, that is keys present in both maps.
+// Entries from other replace conflicting entries of m.
+func (m Map) MergeReport(other Map) (result Map, conflicts uint32) {
+	result = m
+	other.Range(func(key, value interface{}) bool {
+		if _, exists := m.Get(key); exists {
+			conflicts++
+		}
+		result = result.Set(key, value)
+		return true
+	})
+	return result, conflicts
+}
+
+// Equal returns true if both maps have the same keys, with equal
+// values. Values are compared using ==, so incomparable values cause
+// panic, just like incomparable keys.
+func (m Map) Equal(other Map) bool {
+	if m.size != other.size {
+		return false
+	}
+	equal := true
+	m.Range(func(key, value interface{}) bool {
+		otherValue, ok := other.Get(key)
+		equal = ok && otherValue == value
+		return equal
+	})
+	return equal
+}
+
+// EqualIgnoring works like Equal, but skips the given keys in both maps.
+// A key that is ignored does not count towards the map sizes either.
+func (m Map) EqualIgnoring(other Map, ignore ...interface{}) bool {
+	ignored := m.empty()
+	for _, key := range ignore {
+		ignored = ignored.Set(key, present)
+	}
+
+	compared := 0
+	equal := true
+	m.Range(func(key, value interface{}) bool {
+		if ignored.Has(key) {
+			return true
+		}
+		compared++
+		otherValue, ok := other.Get(key)
+		equal = ok && otherValue == value
+		return equal
+	})
+	if !equal {
+		return false
+	}
+
+	other.Range(func(key, value interface{}) bool {
+		if !ignored.Has(key) {
+			compared--
+		}
+		return true
+	})
+	return compared == 0
+}
+
+// IntersectionSize returns the number of keys present in both maps,
+// by looking up each key of the smaller map in the larger map.
+func (m Map) IntersectionSize(other Map) uint32 {
+	smaller, larger := m, other
+	if smaller.size > larger.size {
+		smaller, larger = larger, smaller
+	}
+
+	var shared uint32
+	smaller.Range(func(key, value interface{}) bool {
+		if _, ok := larger.Get(key); ok {
+			shared++
+		}
+		return true
+	})
+	return shared
+}
+
+// Keys returns all keys of the map, in unspecified order.
+func (m Map) Keys() []interface{} {
+	keys := make([]interface{}, 0, m.size)
+	m.root.visit(func(key, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values returns all values of the map, in unspecified order.
+func (m Map) Values() []interface{} {
+	values := make([]interface{}, 0, m.size)
+	m.root.visit(func(key, value interface{}) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// DistinctValues returns a vector of the distinct values of the map,
+// in unspecified order. Values must be comparable, just like keys,
+// otherwise DistinctValues panics.
+func (m Map) DistinctValues() Vector {
+	var seen MapBuilder
+	var distinct VectorBuilder
+	m.root.visit(func(key, value interface{}) bool {
+		if _, ok := seen.Get(value); !ok {
+			seen.Set(value, present)
+			distinct.Append(value)
+		}
+		return true
+	})
+	return distinct.Build()
+}
+
+// ValueCounts returns a map from each distinct value of the map to
+// the number of keys holding that value, as an int. Values must be
+// comparable, just like keys, otherwise ValueCounts panics.
+func (m Map) ValueCounts() Map {
+	var counts MapBuilder
+	m.root.visit(func(key, value interface{}) bool {
+		countValue(&counts, value)
+		return true
+	})
+	return counts.Build()
+}
+
+// countValue increments the int count of value in counts.
+func countValue(counts *MapBuilder, value interface{}) {
+	count, _ := counts.Get(value)
+	if count == nil {
+		count = 0
+	}
+	counts.Set(value, count.(int)+1)
+}
+
+// KeysOfType returns the keys that have the same dynamic type as
+// example. This requires visiting all entries of the map.
+func (m Map) KeysOfType(example interface{}) []interface{} {
+	exampleType := reflect.TypeOf(example)
+	keys := []interface{}{}
+	m.Range(func(key, value interface{}) bool {
+		if reflect.TypeOf(key) == exampleType {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return keys
+}
+
+// MapEntry is a key-value pair of a map.
+type MapEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+func (m Map) entries() []MapEntry {
+	entries := make([]MapEntry, 0, m.size)
+	m.Range(func(key, value interface{}) bool {
+		entries = append(entries, MapEntry{key, value})
+		return true
+	})
+	return entries
+}
+
+// Stream returns a channel receiving all entries of the map,
+// which is closed after the last entry.
+// The channel must be drained, or the goroutine sending the
+// entries leaks. Use StreamUntil to be able to stop early.
+func (m Map) Stream() <-chan MapEntry {
+	return m.StreamUntil(nil)
+}
+
+// StreamUntil works like Stream, but stops sending entries and
+// closes the channel when done is closed.
+func (m Map) StreamUntil(done <-chan struct{}) <-chan MapEntry {
+	entries := make(chan MapEntry)
+	go func() {
+		defer close(entries)
+		m.Range(func(key, value interface{}) bool {
+			select {
+			case entries <- MapEntry{key, value}:
+				return true
+			case <-done:
+				return false
+			}
+		})
+	}()
+	return entries
+}
+
+// Sample returns up to n entries from the map, collected by
+// walking the map and stopping as soon as n entries are found.
+// The entries are spread according to the key hashes, but this is
+// not a uniform random sample.
+func (m Map) Sample(n uint32) []MapEntry {
+	if n > m.size {
+		n = m.size
+	}
+	entries := make([]MapEntry, 0, n)
+	if n == 0 {
+		return entries
+	}
+	m.root.visit(func(key, value interface{}) bool {
+		entries = append(entries, MapEntry{key, value})
+		return uint32(len(entries)) < n
+	})
+	return entries
+}
+
 func (b *bucket) visit(visitor func(key, value interface{}) bool) bool {
 	if len(b.values) > 0 {
 		for _, list := range b.values {
@@ -228,9 +974,78 @@ func (b *bucket) visit(visitor func(key, value interface{}) bool) bool {
 	return true
 }
 
+// String returns a readable representation of the map, with keys
+// and values formatted using fmt. Entries are listed in unspecified
+// order.
+func (m Map) String() string {
+	var b strings.Builder
+	b.WriteString("immutable.Map{")
+	first := true
+	m.Range(func(key, value interface{}) bool {
+		if !first {
+			b.WriteString(", ")
+		}
+		first = false
+		fmt.Fprintf(&b, "%v:%v", key, value)
+		return true
+	})
+	b.WriteString("}")
+	return b.String()
+}
+
+// RangeGrouped calls visitor with the entries of each top level
+// bucket of the map, keeping entries stored close together in the
+// same group. Empty groups are skipped.
+// If visitor returns false, the iteration stops.
+func (m Map) RangeGrouped(visitor func(group []MapEntry) bool) {
+	for _, child := range m.root.buckets {
+		if child == nil {
+			continue
+		}
+		var group []MapEntry
+		child.visit(func(key, value interface{}) bool {
+			group = append(group, MapEntry{key, value})
+			return true
+		})
+		if len(group) > 0 && !visitor(group) {
+			return
+		}
+	}
+}
+
+// Walk exposes the structure of the map by calling visitor for each
+// bucket, level by level, with the tree level of the bucket and a
+// copy of its entries. The root bucket is at level zero, and only the
+// leaf buckets at the deepest level hold entries, so visitor gets
+// empty entries for all other buckets.
+// Leaf buckets are visited in the same order as elements are visited
+// by Range.
+func (m Map) Walk(visitor func(level uint32, entries []MapEntry)) {
+	current := []*bucket{&m.root}
+	for level := uint32(0); len(current) > 0; level++ {
+		var next []*bucket
+		for _, b := range current {
+			var entries []MapEntry
+			for _, list := range b.values {
+				for _, e := range list {
+					entries = append(entries, MapEntry{e.key, e.value})
+				}
+			}
+			visitor(level, entries)
+			for _, child := range b.buckets {
+				if child != nil {
+					next = append(next, child)
+				}
+			}
+		}
+		current = next
+	}
+}
+
 type bucket struct {
 	buckets [bucketCount]*bucket
 	values  []elementList
+	owner   *owner
 }
 
 type elementList []element
@@ -240,14 +1055,46 @@ type element struct {
 	value interface{}
 }
 
+func (m Map) normalize(key interface{}) interface{} {
+	if m.config != nil && m.config.normalizer != nil {
+		return m.config.normalizer(key)
+	}
+	return key
+}
+
+func (m Map) hash(key interface{}) uint32 {
+	if m.config != nil && m.config.hasher != nil {
+		return m.config.hasher(key)
+	}
+	return hashValue(key)
+}
+
+// Hashable is implemented by key types that provide their own hash.
+// Keys are still compared using ==, so keys that are equal must have
+// equal hashes.
+type Hashable interface {
+	Hash() uint32
+}
+
 func hashValue(key interface{}) uint32 {
 	var bytes []uint8
 
 	switch val := key.(type) {
 
+	case nil:
+
 	case string:
 		bytes = []byte(val)
 
+	case []byte:
+		bytes = val
+
+	case bool:
+		bytes = []uint8{0}
+		if val {
+			bytes[0] = 1
+		}
+
 	case int:
 		ptr := unsafe.Pointer(&val)
 		const size = unsafe.Sizeof(val)
@@ -263,6 +1110,21 @@ func hashValue(key interface{}) uint32 {
 		const size = unsafe.Sizeof(val)
 		bytes = (*[size]uint8)(ptr)[:size:size]
 
+	case uint:
+		ptr := unsafe.Pointer(&val)
+		const size = unsafe.Sizeof(val)
+		bytes = (*[size]uint8)(ptr)[:size:size]
+
+	case uint32:
+		ptr := unsafe.Pointer(&val)
+		const size = unsafe.Sizeof(val)
+		bytes = (*[size]uint8)(ptr)[:size:size]
+
+	case uint64:
+		ptr := unsafe.Pointer(&val)
+		const size = unsafe.Sizeof(val)
+		bytes = (*[size]uint8)(ptr)[:size:size]
+
 	case float32:
 		ptr := unsafe.Pointer(&val)
 		const size = unsafe.Sizeof(val)
@@ -273,20 +1135,114 @@ func hashValue(key interface{}) uint32 {
 		const size = unsafe.Sizeof(val)
 		bytes = (*[size]uint8)(ptr)[:size:size]
 
+	case compositeKey:
+		return val.hash()
+
+	case Hashable:
+		return val.Hash()
+
 	default:
-		t := reflect.TypeOf(key)
-		if !t.Comparable() {
+		value := reflect.ValueOf(key)
+		if !value.Type().Comparable() {
 			panic("Key must be comparable")
 		}
+		bytes = appendKeyBytes(nil, value)
+	}
+
+	return hashFunc(bytes)
+}
+
+// appendKeyBytes appends bytes representing a comparable value to
+// buf, such that values that are equal using == give equal bytes.
+// Values are visited field by field and element by element, since
+// their memory representation includes padding, and pointers to
+// string data and interface values.
+func appendKeyBytes(buf []byte, value reflect.Value) []byte {
+	switch value.Kind() {
+	case reflect.Bool:
+		if value.Bool() {
+			return append(buf, 1)
+		}
+		return append(buf, 0)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendUint64(buf, uint64(value.Int()))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendUint64(buf, value.Uint())
+
+	case reflect.Float32, reflect.Float64:
+		return appendFloat64(buf, value.Float())
+
+	case reflect.Complex64, reflect.Complex128:
+		c := value.Complex()
+		return appendFloat64(appendFloat64(buf, real(c)), imag(c))
+
+	case reflect.String:
+		buf = appendUint64(buf, uint64(value.Len()))
+		return append(buf, value.String()...)
 
-		iface := (*ifaceWords)(unsafe.Pointer(&key))
-		ptr := iface.data
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		return appendUint64(buf, uint64(value.Pointer()))
 
-		size := t.Size()
-		bytes = (*[512]uint8)(ptr)[:size:size]
+	case reflect.Interface:
+		if value.IsNil() {
+			return append(buf, 0)
+		}
+		elem := value.Elem()
+		buf = append(buf, elem.Type().String()...)
+		return appendKeyBytes(buf, elem)
+
+	case reflect.Array:
+		if value.Type().Elem() == byteType && value.CanInterface() {
+			start := len(buf)
+			buf = append(buf, make([]byte, value.Len())...)
+			reflect.Copy(reflect.ValueOf(buf[start:]), value)
+			return buf
+		}
+		for i := 0; i < value.Len(); i++ {
+			buf = appendKeyBytes(buf, value.Index(i))
+		}
+		return buf
+
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			buf = appendKeyBytes(buf, value.Field(i))
+		}
+		return buf
 	}
 
-	return hashFunc(bytes)
+	panic("Key must be comparable")
+}
+
+var byteType = reflect.TypeOf(byte(0))
+
+func appendUint64(buf []byte, value uint64) []byte {
+	var bytes [8]byte
+	binary.LittleEndian.PutUint64(bytes[:], value)
+	return append(buf, bytes[:]...)
+}
+
+// appendFloat64 appends the bits of value, with negative zero
+// replaced by zero since they are equal.
+func appendFloat64(buf []byte, value float64) []byte {
+	if value == 0 {
+		value = 0
+	}
+	return appendUint64(buf, math.Float64bits(value))
+}
+
+// keysEqual compares keys using ==, except for []byte keys which
+// are compared by content.
+func keysEqual(a, b interface{}) bool {
+	if aBytes, ok := a.([]byte); ok {
+		bBytes, ok := b.([]byte)
+		return ok && bytes.Equal(aBytes, bBytes)
+	}
+	if _, ok := b.([]byte); ok {
+		return false
+	}
+	return a == b
 }
 
 func mapCapacity(leafCount uint32) uint32 {
@@ -297,11 +1253,3 @@ func mapCapacity(leafCount uint32) uint32 {
 	capacity *= leafCount
 	return capacity
 }
-
-// Hack!
-// ifaceWords is interface{} internal representation, copied
-// from sync.atomic.
-type ifaceWords struct {
-	_    unsafe.Pointer
-	data unsafe.Pointer
-}
@@ -1,7 +1,12 @@
 package immutable
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"math/rand"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -107,6 +112,154 @@ func TestSetByComparableStructWorks(t *testing.T) {
 	}
 }
 
+func TestSetByEqualStructsWorks(t *testing.T) {
+	type small struct {
+		a, b int8
+	}
+	type mixed struct {
+		id    int32
+		name  string
+		extra interface{}
+		zero  float64
+	}
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(small{int8(i), 1}, i)
+		m = m.Set(mixed{int32(i), fmt.Sprint("name", i), fmt.Sprint(i), 0}, i)
+	}
+	for i := 0; i < 100; i++ {
+		if v, ok := m.Get(small{int8(i), 1}); !ok || v != i {
+			t.Fail()
+		}
+		key := mixed{int32(i), fmt.Sprint("name", i), fmt.Sprint(i), math.Copysign(0, -1)}
+		if v, ok := m.Get(key); !ok || v != i {
+			t.Fail()
+		}
+		if hashValue(key) != hashValue(key) {
+			t.Fail()
+		}
+	}
+	if m.Size() != 200 || m.Has(mixed{1, "name1", 1, 0}) {
+		t.Fail()
+	}
+}
+
+type namedByte uint8
+
+func TestSetByNamedByteArrayWorks(t *testing.T) {
+	type wrapper struct {
+		Data [4]namedByte
+	}
+	m := Map{}.Set([4]namedByte{1, 2, 3, 4}, 1).Set(wrapper{[4]namedByte{1, 2, 3, 4}}, 2)
+	if v, ok := m.Get([4]namedByte{1, 2, 3, 4}); !ok || v != 1 {
+		t.Fail()
+	}
+	if v, ok := m.Get(wrapper{[4]namedByte{1, 2, 3, 4}}); !ok || v != 2 {
+		t.Fail()
+	}
+	if m.Has([4]namedByte{1, 2, 3, 5}) || m.Size() != 2 {
+		t.Fail()
+	}
+}
+
+func TestSetByLargeKeysWorks(t *testing.T) {
+	type large struct {
+		data [1024]byte
+		tag  string
+	}
+	type private struct {
+		data [600]byte
+	}
+	var m Map
+	for i := 0; i < 100; i++ {
+		var key large
+		key.data[1000] = byte(i)
+		key.tag = fmt.Sprint(i % 10)
+		m = m.Set(key, i)
+
+		var array [2048]byte
+		array[2000] = byte(i)
+		m = m.Set(array, i)
+
+		var p private
+		p.data[599] = byte(i)
+		m = m.Set(p, i)
+
+		m = m.Set([1]byte{byte(i)}, i)
+	}
+	if m.Size() != 400 {
+		t.Fail()
+	}
+	for i := 0; i < 100; i++ {
+		var key large
+		key.data[1000] = byte(i)
+		key.tag = fmt.Sprint(i % 10)
+		if v, ok := m.Get(key); !ok || v != i {
+			t.Fail()
+		}
+
+		var array [2048]byte
+		array[2000] = byte(i)
+		if v, ok := m.Get(array); !ok || v != i {
+			t.Fail()
+		}
+
+		var p private
+		p.data[599] = byte(i)
+		if v, ok := m.Get(p); !ok || v != i {
+			t.Fail()
+		}
+
+		if v, ok := m.Get([1]byte{byte(i)}); !ok || v != i {
+			t.Fail()
+		}
+	}
+}
+
+func TestSetByStructWithIncomparableInterfaceFails(t *testing.T) {
+	var m Map
+	key := struct {
+		value interface{}
+	}{[]int{}}
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+
+	m = m.Set(key, 4711)
+}
+
+type hashableKey struct {
+	id     int
+	hashed *int
+}
+
+func (k hashableKey) Hash() uint32 {
+	*k.hashed++
+	return uint32(k.id % 3)
+}
+
+func TestSetByHashableKeyWorks(t *testing.T) {
+	hashed := 0
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(hashableKey{i, &hashed}, i)
+	}
+	for i := 0; i < 100; i++ {
+		if v, ok := m.Get(hashableKey{i, &hashed}); !ok || v != i {
+			t.Fail()
+		}
+	}
+	if m.Size() != 100 || hashed < 200 {
+		t.Fail()
+	}
+	other := 0
+	if m.Has(hashableKey{1, &other}) || other != 1 {
+		t.Fail()
+	}
+}
+
 func TestSetByFuncFails(t *testing.T) {
 	var m Map
 	key := func() {}
@@ -131,6 +284,113 @@ func TestSetByMapFails(t *testing.T) {
 	m = m.Set(key, 4711)
 }
 
+func TestHas(t *testing.T) {
+	var m Map
+	if m.Has("key") {
+		t.Fail()
+	}
+	m = m.Set("key", nil)
+	if !m.Has("key") || m.Has("other") {
+		t.Fail()
+	}
+}
+
+func TestGetOrDefault(t *testing.T) {
+	var m Map
+	if m.GetOrDefault("key", 1) != 1 {
+		t.Fail()
+	}
+	m = m.Set("key", nil)
+	if m.GetOrDefault("key", 1) != nil || m.GetOrDefault("other", 2) != 2 {
+		t.Fail()
+	}
+}
+
+func TestUnsignedKeys(t *testing.T) {
+	var m Map
+	for i := uint64(0); i < 100; i++ {
+		m = m.Set(math.MaxUint64-i, i)
+		m = m.Set(uint32(i), i)
+		m = m.Set(uint(i), i)
+	}
+	if m.Size() != 300 {
+		t.Fail()
+	}
+	for i := uint64(0); i < 100; i++ {
+		if v, ok := m.Get(math.MaxUint64 - i); !ok || v != i {
+			t.Fail()
+		}
+		if v, ok := m.Get(uint32(i)); !ok || v != i {
+			t.Fail()
+		}
+		if v, ok := m.Get(uint(i)); !ok || v != i {
+			t.Fail()
+		}
+	}
+	if m.Has(uint64(5)) || m.Has(5) {
+		t.Fail()
+	}
+}
+
+func TestBoolKeys(t *testing.T) {
+	m := Map{}.Set(true, "yes").Set(false, "no").Set(1, "one")
+	if m.Size() != 3 {
+		t.Fail()
+	}
+	if v, _ := m.Get(true); v != "yes" {
+		t.Fail()
+	}
+	if v, _ := m.Get(false); v != "no" {
+		t.Fail()
+	}
+	if m.Delete(true).Has(true) || !m.Delete(true).Has(false) {
+		t.Fail()
+	}
+}
+
+func TestNilKey(t *testing.T) {
+	m := Map{}.Set(nil, "nil").Set(0, "zero")
+	if v, ok := m.Get(nil); !ok || v != "nil" || m.Size() != 2 {
+		t.Fail()
+	}
+	if m.Delete(nil).Has(nil) {
+		t.Fail()
+	}
+}
+
+func TestByteSliceKeys(t *testing.T) {
+	key := []byte("key")
+	m := Map{}.Set(key, 1).Set("key", 2)
+	key[0] = 'K'
+	if v, ok := m.Get([]byte("key")); !ok || v != 1 || m.Size() != 2 {
+		t.Fail()
+	}
+	if m.Has(key) {
+		t.Fail()
+	}
+	m = m.Set([]byte("key"), 3)
+	if v, _ := m.Get([]byte("key")); v != 3 || m.Size() != 2 {
+		t.Fail()
+	}
+	if v, _ := m.Get("key"); v != 2 {
+		t.Fail()
+	}
+	m = m.Delete([]byte("key"))
+	if m.Has([]byte("key")) || !m.Has("key") || m.Size() != 1 {
+		t.Fail()
+	}
+}
+
+func TestHasBySliceFails(t *testing.T) {
+	m := Map{}.Set("key", 1)
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	m.Has([]int{})
+}
+
 func TestResetSameKey(t *testing.T) {
 	var m Map
 	m = m.Set("hej", 2)
@@ -176,6 +436,139 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+func TestDeleteAll(t *testing.T) {
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, i)
+	}
+	d := m.DeleteAll(1, 2, 3, 2, 1000, "missing")
+	if d.Size() != 97 || m.Size() != 100 {
+		t.Fail()
+	}
+	for i := 0; i < 100; i++ {
+		v, ok := d.Get(i)
+		if ok == (i >= 1 && i <= 3) || (ok && v != i) {
+			t.Fail()
+		}
+	}
+	if !m.DeleteAll().Equal(m) {
+		t.Fail()
+	}
+}
+
+func TestInterleavedSetAndDelete(t *testing.T) {
+	var m Map
+	for i := 0; i < 5000; i++ {
+		m = m.Set(i, i)
+		if i%3 == 0 {
+			m = m.Delete(i / 2)
+		}
+	}
+	for i := 5000; i < 10000; i++ {
+		m = m.Set(i, i)
+	}
+
+	deleted := map[int]bool{}
+	for i := 0; i < 5000; i += 3 {
+		deleted[i/2] = true
+	}
+	if m.Size() != uint32(10000-len(deleted)) {
+		t.Fail()
+	}
+	for i := 0; i < 10000; i++ {
+		v, ok := m.Get(i)
+		if ok == deleted[i] || (ok && v != i) {
+			t.Fail()
+		}
+	}
+}
+
+func TestDeleteKeepsConfiguration(t *testing.T) {
+	m := NewMap(WithLoadFactor(0.25), WithKeyNormalizer(func(key interface{}) interface{} {
+		return strings.ToLower(key.(string))
+	}))
+	for i := 0; i < 2000; i++ {
+		m = m.Set(fmt.Sprint("Key", i), i)
+	}
+	capacity := m.Capacity()
+
+	m = m.Delete("key10")
+	if m.Capacity() != capacity || m.LoadFactor() != 0.25 {
+		t.Fail()
+	}
+	m = m.Set("KEY10", "again")
+	for i := 0; i < 2000; i++ {
+		if _, ok := m.Get(fmt.Sprint("KEY", i)); !ok {
+			t.Fail()
+		}
+	}
+	if v, _ := m.Get("key10"); v != "again" {
+		t.Fail()
+	}
+}
+
+func TestDeleteShrinks(t *testing.T) {
+	var m Map
+	for i := 0; i < 20000; i++ {
+		m = m.Set(i, i)
+	}
+	grown := m.Capacity()
+	for i := 200; i < 20000; i++ {
+		m = m.Delete(i)
+	}
+	if m.Capacity() >= grown || m.Capacity() < mapCapacity(leafStartCount) {
+		t.Fail()
+	}
+	if m.Size() != 200 {
+		t.Fail()
+	}
+	for i := 0; i < 200; i++ {
+		if v, _ := m.Get(i); v != i {
+			t.Fail()
+		}
+	}
+
+	for i := 0; i < 200; i++ {
+		m = m.Delete(i)
+	}
+	if m.Capacity() != mapCapacity(leafStartCount) || m.Size() != 0 {
+		t.Fail()
+	}
+	for i := 0; i < 20000; i++ {
+		m = m.Set(i, i)
+	}
+	if m.Capacity() != grown {
+		t.Fail()
+	}
+	for i := 0; i < 20000; i++ {
+		if v, _ := m.Get(i); v != i {
+			t.Fail()
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	m := NewMap(WithLoadFactor(0.25))
+	for i := 0; i < 10000; i++ {
+		m = m.Set(i, i)
+	}
+	for i := 100; i < 10000; i++ {
+		m = m.Delete(i)
+	}
+	compacted := m.Compact()
+	if compacted.Size() != 100 || compacted.LoadFactor() != 0.25 {
+		t.Fail()
+	}
+	if compacted.Capacity() > m.Capacity() {
+		t.Fail()
+	}
+	for i := 0; i < 100; i++ {
+		if v, _ := compacted.Get(i); v != i {
+			t.Fail()
+		}
+	}
+}
+
 func TestAddMany(t *testing.T) {
 	var m Map
 	for i := 0; i < 1000; i++ {
@@ -242,40 +635,1155 @@ func TestRange(t *testing.T) {
 	}
 }
 
-func TestSize(t *testing.T) {
+func TestRangeOrderIsStable(t *testing.T) {
 	var m Map
-	for i := 0; i < 102; i++ {
-		m = m.Set(i, i)
+	for i := 0; i < 10000; i++ {
+		m = m.Set(fmt.Sprint(i), i)
 	}
-	if m.Size() != 102 {
+	m = m.Delete("17")
+
+	var first, second []interface{}
+	m.Range(func(key, value interface{}) bool {
+		first = append(first, key)
+		return true
+	})
+	copied := m
+	copied.Range(func(key, value interface{}) bool {
+		second = append(second, key)
+		return true
+	})
+	if len(first) != len(second) || len(first) != int(m.Size()) {
 		t.Fail()
 	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fail()
+		}
+	}
 }
 
-const (
-	addValues = 1024
-	getValues = 10240
-)
-
-func BenchmarkAddIntsImmutableMap(b *testing.B) {
-	var m atomic.Value
-	m.Store(Map{})
-
-	b.RunParallel(func(pb *testing.PB) {
-		for pb.Next() {
-			for i := 0; i < b.N; i++ {
-				for j := 0; j < addValues; j++ {
-					old := m.Load().(Map)
-					updated := old.Set(j, j)
-					m.Store(updated)
-				}
+func TestRangeGrouped(t *testing.T) {
+	var m Map
+	for i := 0; i < 1000; i++ {
+		m = m.Set(i, i)
+	}
+	seen := map[interface{}]int{}
+	groups := 0
+	m.RangeGrouped(func(group []MapEntry) bool {
+		groups++
+		for _, e := range group {
+			seen[e.Key]++
+			if e.Value != e.Key {
+				t.Fail()
 			}
 		}
+		return true
 	})
-}
-
-func BenchmarkAddIntsImmutableOwnMap(b *testing.B) {
-	var m Map
+	if len(seen) != 1000 || groups > int(bucketCount) || groups < 2 {
+		t.Fail()
+	}
+	for _, count := range seen {
+		if count != 1 {
+			t.Fail()
+		}
+	}
+
+	groups = 0
+	m.RangeGrouped(func(group []MapEntry) bool {
+		groups++
+		return false
+	})
+	if groups != 1 {
+		t.Fail()
+	}
+}
+
+func TestWalk(t *testing.T) {
+	var m Map
+	for i := 0; i < 1000; i++ {
+		m = m.Set(i, i)
+	}
+	seen := map[interface{}]int{}
+	var order []interface{}
+	buckets := make([]uint32, levels+1)
+	last := uint32(0)
+	m.Walk(func(level uint32, entries []MapEntry) {
+		if level < last || level > levels || (len(entries) > 0) != (level == levels) {
+			t.Fail()
+		}
+		last = level
+		buckets[level]++
+		for _, e := range entries {
+			seen[e.Key]++
+			order = append(order, e.Key)
+		}
+	})
+	if buckets[0] != 1 || buckets[1] != bucketCount || buckets[levels] == 0 {
+		t.Fail()
+	}
+	index := 0
+	m.Range(func(key, value interface{}) bool {
+		if index >= len(order) || order[index] != key {
+			t.Fail()
+		}
+		index++
+		return true
+	})
+	if len(seen) != 1000 {
+		t.Fail()
+	}
+	for _, count := range seen {
+		if count != 1 {
+			t.Fail()
+		}
+	}
+}
+
+func TestSize(t *testing.T) {
+	var m Map
+	for i := 0; i < 102; i++ {
+		m = m.Set(i, i)
+	}
+	if m.Size() != 102 {
+		t.Fail()
+	}
+}
+
+func TestSample(t *testing.T) {
+	var m Map
+	if len(m.Sample(10)) != 0 {
+		t.Fail()
+	}
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, i)
+	}
+	sample := m.Sample(10)
+	if len(sample) != 10 {
+		t.Fail()
+	}
+	for _, e := range sample {
+		if v, ok := m.Get(e.Key); !ok || v != e.Value {
+			t.Fail()
+		}
+	}
+	if len(m.Sample(1000)) != 100 {
+		t.Fail()
+	}
+}
+
+func TestSetIfAbsent(t *testing.T) {
+	m := Map{}.Set("a", 1)
+	updated, inserted := m.SetIfAbsent("a", 2)
+	if inserted || updated.Size() != 1 {
+		t.Fail()
+	}
+	if v, _ := updated.Get("a"); v != 1 {
+		t.Fail()
+	}
+
+	updated, inserted = m.SetIfAbsent("b", 2)
+	if !inserted || updated.Size() != 2 || m.Size() != 1 {
+		t.Fail()
+	}
+	if v, _ := updated.Get("b"); v != 2 || m.Has("b") {
+		t.Fail()
+	}
+
+	if testing.AllocsPerRun(10, func() { m.SetIfAbsent("a", 2) }) != 0 {
+		t.Fail()
+	}
+
+	var n Map
+	for i := 0; i < 1000; i++ {
+		n, _ = n.SetIfAbsent(i, i)
+		n, _ = n.SetIfAbsent(i/2, -1)
+	}
+	if n.Size() != 1000 {
+		t.Fail()
+	}
+	for i := 0; i < 1000; i++ {
+		if v, _ := n.Get(i); v != i {
+			t.Fail()
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	increment := func(old interface{}, existed bool) interface{} {
+		if !existed {
+			return 1
+		}
+		return old.(int) + 1
+	}
+	var m Map
+	for i := 0; i < 1000; i++ {
+		m = m.Update(i%100, increment)
+	}
+	if m.Size() != 100 {
+		t.Fail()
+	}
+	for i := 0; i < 100; i++ {
+		if v, _ := m.Get(i); v != 10 {
+			t.Fail()
+		}
+	}
+
+	updated := m.Update(5, increment)
+	if v, _ := updated.Get(5); v != 11 {
+		t.Fail()
+	}
+	if v, _ := m.Get(5); v != 10 {
+		t.Fail()
+	}
+	if v, _ := updated.Get(6); v != 10 || updated.Size() != 100 {
+		t.Fail()
+	}
+}
+
+func TestEqual(t *testing.T) {
+	var a, b Map
+	if !a.Equal(b) {
+		t.Fail()
+	}
+	for i := 0; i < 100; i++ {
+		a = a.Set(i, i)
+	}
+	for i := 99; i >= 0; i-- {
+		b = b.Set(i, i)
+	}
+	if !a.Equal(b) || !b.Equal(a) {
+		t.Fail()
+	}
+	if a.Equal(b.Set(5, "changed")) {
+		t.Fail()
+	}
+	if a.Equal(b.Delete(5)) {
+		t.Fail()
+	}
+	if a.Equal(b.Delete(5).Set(100, 5)) {
+		t.Fail()
+	}
+}
+
+func TestEqualIgnoring(t *testing.T) {
+	a := Map{}.Set("name", "x").Set("value", 1).Set("timestamp", 10)
+	b := Map{}.Set("name", "x").Set("value", 1).Set("timestamp", 20)
+	if a.Equal(b) {
+		t.Fail()
+	}
+	if !a.EqualIgnoring(b, "timestamp") || !b.EqualIgnoring(a, "timestamp") {
+		t.Fail()
+	}
+	if !a.EqualIgnoring(b.Delete("timestamp"), "timestamp") {
+		t.Fail()
+	}
+	if a.EqualIgnoring(b.Set("value", 2), "timestamp") {
+		t.Fail()
+	}
+	if a.EqualIgnoring(b.Set("extra", 1), "timestamp") {
+		t.Fail()
+	}
+}
+
+func TestMapString(t *testing.T) {
+	if (Map{}).String() != "immutable.Map{}" {
+		t.Fail()
+	}
+	if (Map{}).Set("a", 1).String() != "immutable.Map{a:1}" {
+		t.Fail()
+	}
+	s := Map{}.Set(1, "x").Set(2, "y").String()
+	if s != "immutable.Map{1:x, 2:y}" && s != "immutable.Map{2:y, 1:x}" {
+		t.Fail()
+	}
+}
+
+func TestSetAll(t *testing.T) {
+	m := Map{}.Set("existing", 1).Set(0, "replaced")
+	pairs := map[interface{}]interface{}{}
+	for i := 0; i < 1000; i++ {
+		pairs[i] = i
+	}
+	updated := m.SetAll(pairs)
+	if updated.Size() != 1001 || m.Size() != 2 {
+		t.Fail()
+	}
+	for i := 0; i < 1000; i++ {
+		if v, _ := updated.Get(i); v != i {
+			t.Fail()
+		}
+	}
+	if v, _ := m.Get(0); v != "replaced" {
+		t.Fail()
+	}
+
+	chained := testing.AllocsPerRun(5, func() {
+		n := m
+		for key, value := range pairs {
+			n = n.Set(key, value)
+		}
+	})
+	batched := testing.AllocsPerRun(5, func() {
+		m.SetAll(pairs)
+	})
+	if batched*4 > chained*3 {
+		t.Fail()
+	}
+}
+
+func TestSetEntries(t *testing.T) {
+	m := Map{}.Set("existing", 1)
+	updated := m.SetEntries(
+		MapEntry{"a", 1},
+		MapEntry{"b", 2},
+		MapEntry{"a", 3},
+		MapEntry{"existing", 4},
+	)
+	if updated.Size() != 3 || m.Size() != 1 {
+		t.Fail()
+	}
+	for key, expected := range map[string]int{"a": 3, "b": 2, "existing": 4} {
+		if v, _ := updated.Get(key); v != expected {
+			t.Fail()
+		}
+	}
+	if v, _ := m.Get("existing"); v != 1 || m.SetEntries().Size() != 1 {
+		t.Fail()
+	}
+
+	n := NewMap(WithKeyNormalizer(func(key interface{}) interface{} {
+		return strings.ToLower(key.(string))
+	}))
+	n = n.SetEntries(MapEntry{"Key", 1}, MapEntry{"KEY", 2}, MapEntry{"key", 3})
+	if v, _ := n.Get("kEy"); v != 3 || n.Size() != 1 {
+		t.Fail()
+	}
+}
+
+func TestMerge(t *testing.T) {
+	var small, large Map
+	for i := 0; i < 10; i++ {
+		small = small.Set(i, "small")
+	}
+	for i := 5; i < 100; i++ {
+		large = large.Set(i, "large")
+	}
+
+	merged := small.Merge(large)
+	if merged.Size() != 100 {
+		t.Fail()
+	}
+	if v, _ := merged.Get(7); v != "large" {
+		t.Fail()
+	}
+	if v, _ := merged.Get(2); v != "small" {
+		t.Fail()
+	}
+
+	merged = large.Merge(small)
+	if merged.Size() != 100 {
+		t.Fail()
+	}
+	if v, _ := merged.Get(7); v != "small" {
+		t.Fail()
+	}
+
+	if small.Size() != 10 || large.Size() != 95 {
+		t.Fail()
+	}
+	if v, _ := large.Get(7); v != "large" {
+		t.Fail()
+	}
+}
+
+func TestMergeKeepsConfiguration(t *testing.T) {
+	ci := NewMap(WithKeyNormalizer(func(key interface{}) interface{} {
+		return strings.ToLower(key.(string))
+	})).Set("a", 1)
+	plain := NewMapWithHasher(func(key interface{}) uint32 {
+		return uint32(key.(int))
+	})
+	for i := 0; i < 10; i++ {
+		plain = plain.Set(i, i)
+	}
+	var strs Map
+	for i := 0; i < 10; i++ {
+		strs = strs.Set(fmt.Sprint("K", i), i)
+	}
+
+	merged := ci.Merge(strs)
+	if merged.Size() != 11 || !merged.Has("A") || !merged.Has("k5") {
+		t.Fail()
+	}
+
+	merged = Map{}.Set("x", 1).Merge(plain)
+	if merged.Size() != 11 || !merged.Has("x") || !merged.Has(5) {
+		t.Fail()
+	}
+}
+
+func TestMergeMapsWith(t *testing.T) {
+	sum := func(key, a, b interface{}) interface{} {
+		return a.(int) + b.(int)
+	}
+	var shards []Map
+	for shard := 1; shard <= 4; shard++ {
+		var m Map
+		for i := 0; i < 10*shard; i++ {
+			m = m.Set(i, shard)
+		}
+		shards = append(shards, m)
+	}
+
+	total := MergeMapsWith(sum, shards...)
+	if total.Size() != 40 {
+		t.Fail()
+	}
+	for i := 0; i < 40; i++ {
+		expected := 0
+		for shard := 1; shard <= 4; shard++ {
+			if i < 10*shard {
+				expected += shard
+			}
+		}
+		if v, _ := total.Get(i); v != expected {
+			t.Fail()
+		}
+	}
+	if v, _ := shards[0].Get(0); v != 1 || shards[0].Size() != 10 {
+		t.Fail()
+	}
+
+	pair := shards[0].MergeWith(shards[1], sum)
+	if v, _ := pair.Get(0); v != 3 || pair.Size() != 20 {
+		t.Fail()
+	}
+	if MergeMapsWith(sum).Size() != 0 || !MergeMapsWith(sum, shards[2]).Equal(shards[2]) {
+		t.Fail()
+	}
+}
+
+func TestMergeReport(t *testing.T) {
+	var a, b Map
+	for i := 0; i < 10; i++ {
+		a = a.Set(i, "a")
+	}
+	for i := 7; i < 20; i++ {
+		b = b.Set(i, "b")
+	}
+	merged, conflicts := a.MergeReport(b)
+	if conflicts != 3 {
+		t.Fail()
+	}
+	if merged.Size() != 20 {
+		t.Fail()
+	}
+	if v, _ := merged.Get(8); v != "b" {
+		t.Fail()
+	}
+	if v, _ := merged.Get(2); v != "a" {
+		t.Fail()
+	}
+}
+
+func TestDistinctValues(t *testing.T) {
+	var m Map
+	statuses := []string{"new", "active", "done"}
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, statuses[i%len(statuses)])
+	}
+	distinct := m.DistinctValues()
+	if distinct.Size() != 3 {
+		t.Fail()
+	}
+	found := map[interface{}]bool{}
+	r := distinct.Elements()
+	for r.Next() {
+		found[r.Get()] = true
+	}
+	for _, status := range statuses {
+		if !found[status] {
+			t.Fail()
+		}
+	}
+	if (Map{}).DistinctValues().Size() != 0 {
+		t.Fail()
+	}
+}
+
+func TestValueCounts(t *testing.T) {
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, i%3)
+	}
+	counts := m.ValueCounts()
+	if counts.Size() != 3 {
+		t.Fail()
+	}
+	for value, expected := range []int{34, 33, 33} {
+		if count, _ := counts.Get(value); count != expected {
+			t.Fail()
+		}
+	}
+	if (Map{}).ValueCounts().Size() != 0 {
+		t.Fail()
+	}
+}
+
+func TestKeysOfType(t *testing.T) {
+	var m Map
+	for i := 0; i < 10; i++ {
+		m = m.Set(i, i)
+	}
+	m = m.Set("one", 1)
+	m = m.Set("two", 2)
+
+	keys := m.KeysOfType("")
+	if len(keys) != 2 {
+		t.Fail()
+	}
+	for _, key := range keys {
+		if _, isString := key.(string); !isString {
+			t.Fail()
+		}
+	}
+	if len(m.KeysOfType(0)) != 10 {
+		t.Fail()
+	}
+	if len(m.KeysOfType(0.0)) != 0 {
+		t.Fail()
+	}
+}
+
+func TestFilter(t *testing.T) {
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, i)
+	}
+	calls := 0
+	filtered := m.Filter(func(key, value interface{}) bool {
+		calls++
+		return key.(int) < 10
+	})
+	if calls != 100 || filtered.Size() != 10 || m.Size() != 100 {
+		t.Fail()
+	}
+	if _, ok := filtered.Get(5); !ok {
+		t.Fail()
+	}
+	if _, ok := filtered.Get(50); ok {
+		t.Fail()
+	}
+
+	empty := m.Filter(func(key, value interface{}) bool {
+		return false
+	})
+	if empty.Size() != 0 {
+		t.Fail()
+	}
+	if _, ok := empty.Get(5); ok {
+		t.Fail()
+	}
+	if empty.Set(1, 1).Size() != 1 {
+		t.Fail()
+	}
+}
+
+func TestMapValues(t *testing.T) {
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, i)
+	}
+	type wrapped struct {
+		value interface{}
+	}
+	mapped := m.MapValues(func(key, value interface{}) interface{} {
+		return wrapped{value}
+	})
+	if mapped.Size() != m.Size() {
+		t.Fail()
+	}
+	for i := 0; i < 100; i++ {
+		if v, _ := mapped.Get(i); v != (wrapped{i}) {
+			t.Fail()
+		}
+		if v, _ := m.Get(i); v != i {
+			t.Fail()
+		}
+	}
+}
+
+func TestTransformationChaining(t *testing.T) {
+	var m, other Map
+	for i := 0; i < 10; i++ {
+		m = m.Set(i, i)
+	}
+	other = other.Set(100, 100)
+
+	result := m.Filter(func(key, value interface{}) bool {
+		return value.(int)%2 == 0
+	}).MapValues(func(key, value interface{}) interface{} {
+		return value.(int) * 10
+	}).Merge(other)
+
+	if result.Size() != 6 {
+		t.Fail()
+	}
+	if v, _ := result.Get(4); v != 40 {
+		t.Fail()
+	}
+	if _, ok := result.Get(3); ok {
+		t.Fail()
+	}
+	if v, _ := result.Get(100); v != 100 {
+		t.Fail()
+	}
+	if m.Size() != 10 {
+		t.Fail()
+	}
+}
+
+func TestDefaultLoadFactor(t *testing.T) {
+	var m Map
+	if m.LoadFactor() != 0.5 {
+		t.Fail()
+	}
+	for i := 0; i < 2048; i++ {
+		m = m.Set(i, i)
+	}
+	if m.Capacity() != 4096 {
+		t.Fail()
+	}
+	m = m.Set(-1, -1)
+	if m.Capacity() != 8192 {
+		t.Fail()
+	}
+}
+
+func TestCustomLoadFactor(t *testing.T) {
+	m := NewMap(WithLoadFactor(0.25))
+	if m.LoadFactor() != 0.25 {
+		t.Fail()
+	}
+	for i := 0; i < 1024; i++ {
+		m = m.Set(i, i)
+	}
+	if m.Capacity() != 4096 {
+		t.Fail()
+	}
+	m = m.Set(-1, -1)
+	if m.Capacity() != 8192 {
+		t.Fail()
+	}
+	m = m.Delete(-1)
+	if m.LoadFactor() != 0.25 {
+		t.Fail()
+	}
+	for i := 0; i < 1024; i++ {
+		if v, ok := m.Get(i); !ok || v != i {
+			t.Fail()
+		}
+	}
+}
+
+func TestInvalidLoadFactorFails(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	NewMap(WithLoadFactor(0))
+}
+
+func TestStream(t *testing.T) {
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, i)
+	}
+	received := 0
+	for e := range m.Stream() {
+		if e.Key != e.Value {
+			t.Fail()
+		}
+		received++
+	}
+	if received != 100 {
+		t.Fail()
+	}
+}
+
+func TestStreamUntil(t *testing.T) {
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, i)
+	}
+	done := make(chan struct{})
+	entries := m.StreamUntil(done)
+	<-entries
+	close(done)
+
+	remaining := 0
+	for range entries {
+		remaining++
+	}
+	if remaining >= 99 {
+		t.Fail()
+	}
+}
+
+func TestMapWithHasher(t *testing.T) {
+	hashed := 0
+	m := NewMapWithHasher(func(key interface{}) uint32 {
+		hashed++
+		return uint32(key.(int) % 10)
+	})
+	for i := 0; i < 1000; i++ {
+		m = m.Set(i, i)
+	}
+	for i := 0; i < 1000; i++ {
+		if v, ok := m.Get(i); !ok || v != i {
+			t.Fail()
+		}
+	}
+	m = m.Delete(10)
+	if m.Has(10) || m.Size() != 999 {
+		t.Fail()
+	}
+	if hashed < 2000 {
+		t.Fail()
+	}
+
+	hashed = 0
+	plain := Map{}.Set(1, 1).Set("text", 2)
+	if plain.Size() != 2 || hashed != 0 {
+		t.Fail()
+	}
+}
+
+func TestRangeByValue(t *testing.T) {
+	var m Map
+	m = m.Set("a", 5).Set("b", 1).Set("c", 9).Set("d", 7).Set("e", 3)
+
+	var top []interface{}
+	m.RangeByValue(func(a, b interface{}) bool {
+		return a.(int) > b.(int)
+	}, func(key, value interface{}) bool {
+		top = append(top, key)
+		return len(top) < 3
+	})
+	if len(top) != 3 || top[0] != "c" || top[1] != "d" || top[2] != "a" {
+		t.Fail()
+	}
+}
+
+func TestTopN(t *testing.T) {
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(fmt.Sprint(i), (i*37)%100)
+	}
+	top := m.TopN(3, func(a, b interface{}) bool {
+		return a.(int) < b.(int)
+	})
+	if len(top) != 3 {
+		t.Fail()
+	}
+	for i, e := range top {
+		if e.Value != 99-i {
+			t.Fail()
+		}
+		if v, _ := m.Get(e.Key); v != e.Value {
+			t.Fail()
+		}
+	}
+}
+
+func TestGetOrCompute(t *testing.T) {
+	computed := 0
+	compute := func() interface{} {
+		computed++
+		return 42
+	}
+	evicted := 0
+	onEvict := func(old interface{}) {
+		evicted++
+	}
+
+	var m Map
+	updated, value := m.GetOrCompute("key", compute, onEvict)
+	if value != 42 || updated.Size() != 1 || m.Size() != 0 {
+		t.Fail()
+	}
+	again, value := updated.GetOrCompute("key", compute, onEvict)
+	if value != 42 || again.Size() != 1 {
+		t.Fail()
+	}
+	if computed != 1 || evicted != 0 {
+		t.Fail()
+	}
+	if _, value := m.GetOrCompute("other", compute, nil); value != 42 || computed != 2 {
+		t.Fail()
+	}
+}
+
+func TestKeyNormalizer(t *testing.T) {
+	m := NewMap(WithKeyNormalizer(func(key interface{}) interface{} {
+		return strings.ToLower(key.(string))
+	}))
+	m = m.Set("key", 1)
+	if v, ok := m.Get("KEY"); !ok || v != 1 {
+		t.Fail()
+	}
+	m = m.Set("Key", 2)
+	if m.Size() != 1 {
+		t.Fail()
+	}
+	if v, _ := m.Get("key"); v != 2 {
+		t.Fail()
+	}
+	m.Range(func(key, value interface{}) bool {
+		if key != "key" {
+			t.Fail()
+		}
+		return true
+	})
+	m = m.Delete("kEy")
+	if m.Size() != 0 {
+		t.Fail()
+	}
+}
+
+func TestKeyNormalizerCopies(t *testing.T) {
+	m := NewMap(WithKeyNormalizer(func(key interface{}) interface{} {
+		return strings.ToLower(key.(string))
+	}))
+	m = m.Set("A", 1).Set("b", 2).Set("C", 3)
+	keep := func(key, value interface{}) bool {
+		return true
+	}
+	same := func(key, value interface{}) interface{} {
+		return value
+	}
+	data, err := m.MarshalBinary()
+	decoded := m.empty()
+	if err != nil || decoded.UnmarshalBinary(data) != nil {
+		t.Fail()
+	}
+	for _, copied := range []Map{m.Compact(), m.Filter(keep), m.MapValues(same), m.OverlayDelta(Map{}), decoded} {
+		if copied.Size() != 3 || !copied.Equal(m) || !copied.EqualIgnoring(m, "a") {
+			t.Fail()
+		}
+		for key, expected := range map[string]int{"a": 1, "B": 2, "c": 3} {
+			if v, _ := copied.Get(key); v != expected {
+				t.Fail()
+			}
+		}
+		copied.Range(func(key, value interface{}) bool {
+			if key != strings.ToLower(key.(string)) {
+				t.Fail()
+			}
+			return true
+		})
+	}
+	if !m.KeySet().Has("a") || m.KeySet().Size() != 3 {
+		t.Fail()
+	}
+}
+
+func TestFoldSorted(t *testing.T) {
+	var forward, backward Map
+	for i := 0; i < 10; i++ {
+		forward = forward.Set(fmt.Sprint("k", i), i)
+	}
+	for i := 9; i >= 0; i-- {
+		backward = backward.Set(fmt.Sprint("k", i), i)
+	}
+	less := func(a, b interface{}) bool {
+		return a.(string) < b.(string)
+	}
+	concat := func(acc, key, value interface{}) interface{} {
+		return fmt.Sprintf("%v%v=%v;", acc, key, value)
+	}
+	const expected = "k0=0;k1=1;k2=2;k3=3;k4=4;k5=5;k6=6;k7=7;k8=8;k9=9;"
+	if forward.FoldSorted(less, "", concat) != expected {
+		t.Fail()
+	}
+	if backward.FoldSorted(less, "", concat) != expected {
+		t.Fail()
+	}
+	if (Map{}).FoldSorted(less, "initial", concat) != "initial" {
+		t.Fail()
+	}
+}
+
+func TestAccumulate(t *testing.T) {
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, 1)
+	}
+	visited := 0
+	sum := m.Accumulate(0, func(acc, key, value interface{}) (interface{}, bool) {
+		visited++
+		total := acc.(int) + value.(int)
+		return total, total < 10
+	})
+	if sum != 10 || visited != 10 {
+		t.Fail()
+	}
+	if (Map{}).Accumulate("initial", nil) != "initial" {
+		t.Fail()
+	}
+}
+
+func TestIntersectionSize(t *testing.T) {
+	var a, b, c Map
+	for i := 0; i < 100; i++ {
+		a = a.Set(i, i)
+	}
+	for i := 90; i < 300; i++ {
+		b = b.Set(i, i)
+	}
+	for i := 1000; i < 1010; i++ {
+		c = c.Set(i, i)
+	}
+	if a.IntersectionSize(b) != 10 || b.IntersectionSize(a) != 10 {
+		t.Fail()
+	}
+	if a.IntersectionSize(c) != 0 {
+		t.Fail()
+	}
+	if a.IntersectionSize(Map{}) != 0 {
+		t.Fail()
+	}
+}
+
+func TestOverlayDelta(t *testing.T) {
+	var base Map
+	for i := 0; i < 100; i++ {
+		base = base.Set(i, i)
+	}
+	m := base.Set(3, "changed").Set(200, 200).Delete(50)
+
+	delta := m.OverlayDelta(base)
+	if delta.Size() != 2 {
+		t.Fail()
+	}
+	if v, _ := delta.Get(3); v != "changed" {
+		t.Fail()
+	}
+	if v, _ := delta.Get(200); v != 200 {
+		t.Fail()
+	}
+	merged := base.Merge(delta)
+	m.Range(func(key, value interface{}) bool {
+		if v, _ := merged.Get(key); v != value {
+			t.Fail()
+		}
+		return true
+	})
+}
+
+func TestRangeContext(t *testing.T) {
+	var m Map
+	for i := 0; i < 1000; i++ {
+		m = m.Set(i, i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	visited := 0
+	err := m.RangeContext(ctx, func(key, value interface{}) bool {
+		visited++
+		if visited == 100 {
+			cancel()
+		}
+		return true
+	})
+	if err != context.Canceled || visited < 100 || visited >= 1000 {
+		t.Fail()
+	}
+
+	visited = 0
+	err = m.RangeContext(context.Background(), func(key, value interface{}) bool {
+		visited++
+		return true
+	})
+	if err != nil || visited != 1000 {
+		t.Fail()
+	}
+}
+
+func TestRangeErr(t *testing.T) {
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, i)
+	}
+	failure := errors.New("failure")
+	visited := 0
+	err := m.RangeErr(func(key, value interface{}) error {
+		visited++
+		if visited == 10 {
+			return failure
+		}
+		return nil
+	})
+	if err != failure || visited != 10 {
+		t.Fail()
+	}
+
+	visited = 0
+	err = m.RangeErr(func(key, value interface{}) error {
+		visited++
+		return nil
+	})
+	if err != nil || visited != 100 {
+		t.Fail()
+	}
+}
+
+func TestSafeRange(t *testing.T) {
+	var m Map
+	for i := 0; i < 10; i++ {
+		m = m.Set(i, i)
+	}
+	visited := 0
+	err := m.SafeRange(func(key, value interface{}) bool {
+		visited++
+		if visited == 3 {
+			panic("bad visitor")
+		}
+		return true
+	})
+	if err == nil || visited != 3 {
+		t.Fail()
+	}
+
+	err = m.SafeRange(func(key, value interface{}) bool {
+		return true
+	})
+	if err != nil {
+		t.Fail()
+	}
+}
+
+func TestRangeLimit(t *testing.T) {
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, i)
+	}
+	for _, limit := range []uint32{0, 1, 10, 100, 1000} {
+		visited := uint32(0)
+		m.RangeLimit(limit, func(key, value interface{}) bool {
+			visited++
+			return true
+		})
+		if visited > limit || (visited < limit && visited != m.Size()) {
+			t.Fail()
+		}
+	}
+
+	visited := 0
+	m.RangeLimit(10, func(key, value interface{}) bool {
+		visited++
+		return visited < 5
+	})
+	if visited != 5 {
+		t.Fail()
+	}
+}
+
+func TestKeys(t *testing.T) {
+	var m Map
+	keys := m.Keys()
+	if keys == nil || len(keys) != 0 {
+		t.Fail()
+	}
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, i)
+	}
+	keys = m.Keys()
+	if len(keys) != 100 {
+		t.Fail()
+	}
+	seen := map[interface{}]bool{}
+	for _, key := range keys {
+		seen[key] = true
+	}
+	if len(seen) != 100 {
+		t.Fail()
+	}
+}
+
+func TestValues(t *testing.T) {
+	var m Map
+	values := m.Values()
+	if values == nil || len(values) != 0 {
+		t.Fail()
+	}
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, 1)
+	}
+	sum := 0
+	for _, value := range m.Values() {
+		sum += value.(int)
+	}
+	if sum != 100 {
+		t.Fail()
+	}
+}
+
+const (
+	addValues = 1024
+	getValues = 10240
+)
+
+// leafSlots counts the entry list slots of all leaf buckets.
+func leafSlots(b *bucket) int {
+	if b == nil {
+		return 0
+	}
+	slots := len(b.values)
+	for _, child := range b.buckets {
+		slots += leafSlots(child)
+	}
+	return slots
+}
+
+func BenchmarkDeleteMostImmutableMap(b *testing.B) {
+	var full Map
+	for i := 0; i < 100000; i++ {
+		full = full.Set(i, i)
+	}
+	b.ResetTimer()
+
+	var m Map
+	for n := 0; n < b.N; n++ {
+		m = full
+		for i := 1000; i < 100000; i++ {
+			m = m.Delete(i)
+		}
+	}
+	b.ReportMetric(float64(leafSlots(&full.root)), "slots-before")
+	b.ReportMetric(float64(leafSlots(&m.root)), "slots-after")
+}
+
+func BenchmarkAddIntsImmutableMap(b *testing.B) {
+	var m atomic.Value
+	m.Store(Map{})
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < addValues; j++ {
+					old := m.Load().(Map)
+					updated := old.Set(j, j)
+					m.Store(updated)
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkAddIntsImmutableOwnMap(b *testing.B) {
+	var m Map
 
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
@@ -288,6 +1796,36 @@ func BenchmarkAddIntsImmutableOwnMap(b *testing.B) {
 	})
 }
 
+func BenchmarkAddIntsImmutableMapBuilder(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		var builder MapBuilder
+		for j := 0; j < addValues; j++ {
+			builder.Set(j, j)
+		}
+		builder.Build()
+	}
+}
+
+func BenchmarkAddManyIntsImmutableMapBuilder(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		var builder MapBuilder
+		for j := 0; j < getValues; j++ {
+			builder.Set(j, j)
+		}
+		builder.Build()
+	}
+}
+
+func BenchmarkAddManyIntsPresizedImmutableMapBuilder(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		builder := NewMapBuilder(getValues)
+		for j := 0; j < getValues; j++ {
+			builder.Set(j, j)
+		}
+		builder.Build()
+	}
+}
+
 func BenchmarkAddIntsGoMap(b *testing.B) {
 	m := map[int]int{}
 	var mutex sync.Mutex
@@ -0,0 +1,138 @@
+package immutable
+
+import (
+	"context"
+)
+
+// MapBuilder builds a Map by modifying its storage in place,
+// avoiding the copying made by each Map operation.
+// Storage shared with other maps is copied before being modified,
+// so building never affects existing maps.
+//
+// A MapBuilder is not safe for concurrent use, and cannot be
+// used after Build has been called.
+//
+// The zero MapBuilder is empty and ready for use.
+type MapBuilder struct {
+	m     Map
+	owner *owner
+	built bool
+}
+
+// NewMapBuilder returns an empty MapBuilder with capacity for the
+// expected number of entries, so that adding up to expected entries
+// does not grow and rehash the map.
+func NewMapBuilder(expected uint32) *MapBuilder {
+	return Map{}.sizedFor(expected).Builder()
+}
+
+// Builder returns a MapBuilder starting out with the entries and
+// configuration of the map.
+func (m Map) Builder() *MapBuilder {
+	return &MapBuilder{
+		m: m,
+	}
+}
+
+// Set adds an entry to the map.
+func (b *MapBuilder) Set(key, value interface{}) {
+	b.m = b.m.set(key, value, b.edit())
+}
+
+// Get retrieves a value from the map.
+func (b *MapBuilder) Get(key interface{}) (interface{}, bool) {
+	return b.m.Get(key)
+}
+
+// Delete removes entries matching the key from the map.
+func (b *MapBuilder) Delete(key interface{}) {
+	b.m = b.m.delete(key, b.edit())
+}
+
+// Size returns the current number of entries.
+func (b *MapBuilder) Size() uint32 {
+	return b.m.size
+}
+
+// Build returns the built Map.
+// The builder cannot be used after calling Build.
+func (b *MapBuilder) Build() Map {
+	b.edit()
+	b.built = true
+	b.owner = nil
+	return b.m
+}
+
+func (b *MapBuilder) edit() *owner {
+	if b.built {
+		panic("MapBuilder used after Build")
+	}
+	if b.owner == nil {
+		b.owner = &owner{}
+	}
+	return b.owner
+}
+
+// MapFromChannel returns a map with all entries received from ch,
+// until it is closed. Entries received later replace earlier entries
+// with the same key.
+func MapFromChannel(ch <-chan MapEntry) Map {
+	var builder MapBuilder
+	for e := range ch {
+		builder.Set(e.Key, e.Value)
+	}
+	return builder.Build()
+}
+
+// MapFromChannelContext works like MapFromChannel, but stops when
+// the context is done and returns the context error together with
+// the entries received so far.
+func MapFromChannelContext(ctx context.Context, ch <-chan MapEntry) (Map, error) {
+	var builder MapBuilder
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return builder.Build(), nil
+			}
+			builder.Set(e.Key, e.Value)
+		case <-ctx.Done():
+			return builder.Build(), ctx.Err()
+		}
+	}
+}
+
+// MapTxn collects the operations of a map transaction,
+// see Map.Transact.
+type MapTxn struct {
+	builder *MapBuilder
+}
+
+// Set adds an entry to the transaction result.
+func (txn *MapTxn) Set(key, value interface{}) {
+	txn.builder.Set(key, value)
+}
+
+// Delete removes entries matching the key from the transaction result.
+func (txn *MapTxn) Delete(key interface{}) {
+	txn.builder.Delete(key)
+}
+
+// Get retrieves a value from the transaction result, including the
+// effects of operations made so far.
+func (txn *MapTxn) Get(key interface{}) (interface{}, bool) {
+	return txn.builder.Get(key)
+}
+
+// Transact calls ops with a transaction, and returns the map
+// resulting from applying all operations of the transaction.
+// Operations are applied using a builder, so no intermediate
+// map versions are created.
+// The transaction cannot be used after ops returns.
+func (m Map) Transact(ops func(txn *MapTxn)) Map {
+	txn := MapTxn{
+		builder: m.Builder(),
+	}
+	ops(&txn)
+	return txn.builder.Build()
+}
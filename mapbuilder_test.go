@@ -0,0 +1,172 @@
+package immutable
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMapBuilderSet(t *testing.T) {
+	var b MapBuilder
+	for i := 0; i < 10000; i++ {
+		b.Set(i, i)
+	}
+	b.Delete(10)
+	m := b.Build()
+	if m.Size() != 9999 {
+		t.Fail()
+	}
+	for i := 0; i < 10000; i++ {
+		v, ok := m.Get(i)
+		if i == 10 {
+			if ok {
+				t.Fail()
+			}
+		} else if !ok || v != i {
+			t.Fail()
+		}
+	}
+}
+
+func TestNewMapBuilder(t *testing.T) {
+	b := NewMapBuilder(10000)
+	capacity := b.m.Capacity()
+	if capacity/2 < 10000 || capacity/4 >= 10000 {
+		t.Fail()
+	}
+	for i := 0; i < 10000; i++ {
+		b.Set(i, i)
+	}
+	m := b.Build()
+	if m.Capacity() != capacity || m.Size() != 10000 {
+		t.Fail()
+	}
+	for i := 0; i < 10000; i++ {
+		if v, _ := m.Get(i); v != i {
+			t.Fail()
+		}
+	}
+	if NewMapBuilder(0).Build().Size() != 0 {
+		t.Fail()
+	}
+}
+
+func TestMapBuilderDoesNotAffectSource(t *testing.T) {
+	var source Map
+	for i := 0; i < 100; i++ {
+		source = source.Set(i, i)
+	}
+	b := source.Builder()
+	for i := 0; i < 100; i++ {
+		b.Set(i, -i)
+	}
+	b.Delete(0)
+	b.Set("new", 1)
+	built := b.Build()
+
+	if source.Size() != 100 || built.Size() != 100 {
+		t.Fail()
+	}
+	for i := 1; i < 100; i++ {
+		if v, _ := source.Get(i); v != i {
+			t.Fail()
+		}
+		if v, _ := built.Get(i); v != -i {
+			t.Fail()
+		}
+	}
+	if _, ok := source.Get(0); !ok {
+		t.Fail()
+	}
+}
+
+func TestMapBuilderUseAfterBuildFails(t *testing.T) {
+	b := Map{}.Set(1, 1).Builder()
+	b.Set(2, 2)
+	built := b.Build()
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+		if built.Size() != 2 || built.Has(3) {
+			t.Fail()
+		}
+	}()
+	b.Set(3, 3)
+}
+
+func TestMapFromChannel(t *testing.T) {
+	ch := make(chan MapEntry)
+	go func() {
+		for i := 0; i < 100; i++ {
+			ch <- MapEntry{i, i * 2}
+		}
+		close(ch)
+	}()
+	m := MapFromChannel(ch)
+	if m.Size() != 100 {
+		t.Fail()
+	}
+	for i := 0; i < 100; i++ {
+		if v, _ := m.Get(i); v != i*2 {
+			t.Fail()
+		}
+	}
+}
+
+func TestMapFromChannelContext(t *testing.T) {
+	ch := make(chan MapEntry, 1)
+	ch <- MapEntry{"key", "value"}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	var m Map
+	var err error
+	go func() {
+		m, err = MapFromChannelContext(ctx, ch)
+		close(done)
+	}()
+	cancel()
+	<-done
+
+	if err != context.Canceled {
+		t.Fail()
+	}
+	if m.Size() > 1 {
+		t.Fail()
+	}
+}
+
+func TestMapTransact(t *testing.T) {
+	var m Map
+	for i := 0; i < 10; i++ {
+		m = m.Set(i, i)
+	}
+	result := m.Transact(func(txn *MapTxn) {
+		txn.Set("a", 1)
+		txn.Set(3, 30)
+		txn.Delete(4)
+		txn.Delete(5)
+		if v, _ := txn.Get(3); v != 30 {
+			t.Fail()
+		}
+	})
+
+	if result.Size() != 9 {
+		t.Fail()
+	}
+	if v, _ := result.Get(3); v != 30 {
+		t.Fail()
+	}
+	if _, ok := result.Get(4); ok {
+		t.Fail()
+	}
+	if v, _ := result.Get("a"); v != 1 {
+		t.Fail()
+	}
+	if m.Size() != 10 {
+		t.Fail()
+	}
+	if v, _ := m.Get(3); v != 3 {
+		t.Fail()
+	}
+}
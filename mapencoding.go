@@ -0,0 +1,113 @@
+package immutable
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// mapEncodingVersion is the current version of the map binary
+// encoding.
+//
+// Version 1 layout:
+//
+//	byte 0: version
+//	byte 1-: gob encoded []MapEntry holding all entries
+const mapEncodingVersion byte = 1
+
+// MarshalBinary encodes the map entries using gob, prefixed by
+// a format version byte.
+// Concrete key and value types need to be registered using
+// gob.Register, just as for any gob encoded interface values.
+func (m Map) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(mapEncodingVersion)
+	err := gob.NewEncoder(&buf).Encode(m.entries())
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a map encoded by MarshalBinary, replacing
+// the entries of the receiver while keeping its configuration.
+// Data encoded using an unknown format version is rejected.
+func (m *Map) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("immutable: empty map encoding")
+	}
+	version := data[0]
+	if version != mapEncodingVersion {
+		return fmt.Errorf("immutable: unsupported map encoding version %d", version)
+	}
+
+	var entries []MapEntry
+	err := gob.NewDecoder(bytes.NewReader(data[1:])).Decode(&entries)
+	if err != nil {
+		return err
+	}
+
+	decoded := m.empty()
+	for _, e := range entries {
+		decoded = decoded.Set(e.Key, e.Value)
+	}
+	*m = decoded
+	return nil
+}
+
+// GobEncode encodes the map for gob, using the MarshalBinary encoding.
+func (m Map) GobEncode() ([]byte, error) {
+	return m.MarshalBinary()
+}
+
+// GobDecode decodes a map encoded by GobEncode.
+func (m *Map) GobDecode(data []byte) error {
+	return m.UnmarshalBinary(data)
+}
+
+// MarshalJSON encodes the map as a JSON object, with values encoded
+// using the encoding/json rules.
+// All keys must be strings, otherwise an error is returned.
+func (m Map) MarshalJSON() ([]byte, error) {
+	object := make(map[string]interface{}, m.size)
+	var err error
+	m.Range(func(key, value interface{}) bool {
+		name, ok := key.(string)
+		if !ok {
+			err = fmt.Errorf("immutable: cannot encode map key of type %T as JSON", key)
+			return false
+		}
+		object[name] = value
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(object)
+}
+
+// UnmarshalJSON decodes a JSON object into the map, replacing the
+// entries of the receiver while keeping its configuration.
+// Values are decoded just as when decoding into a
+// map[string]interface{}. Following the encoding/json convention,
+// decoding null leaves the map unchanged.
+func (m *Map) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+
+	var object map[string]interface{}
+	err := json.Unmarshal(data, &object)
+	if err != nil {
+		return err
+	}
+
+	decoded := m.empty()
+	for key, value := range object {
+		decoded = decoded.Set(key, value)
+	}
+	*m = decoded
+	return nil
+}
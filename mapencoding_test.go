@@ -0,0 +1,159 @@
+package immutable
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+)
+
+func TestMarshalBinaryRoundTrip(t *testing.T) {
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, i*2)
+	}
+	m = m.Set("key", "value")
+
+	data, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[0] != mapEncodingVersion {
+		t.Fail()
+	}
+
+	var decoded Map
+	err = decoded.UnmarshalBinary(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Size() != m.Size() {
+		t.Fail()
+	}
+	m.Range(func(key, value interface{}) bool {
+		if v, ok := decoded.Get(key); !ok || v != value {
+			t.Fail()
+		}
+		return true
+	})
+}
+
+func TestUnmarshalBinaryUnknownVersion(t *testing.T) {
+	m := Map{}.Set("key", "value")
+	data, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[0]++
+
+	decoded := Map{}.Set("existing", 1)
+	err = decoded.UnmarshalBinary(data)
+	if err == nil {
+		t.Fail()
+	}
+	if decoded.Size() != 1 {
+		t.Fail()
+	}
+}
+
+func TestUnmarshalBinaryEmpty(t *testing.T) {
+	var decoded Map
+	if decoded.UnmarshalBinary(nil) == nil {
+		t.Fail()
+	}
+}
+
+func TestGobRoundTrip(t *testing.T) {
+	type message struct {
+		Name    string
+		Entries Map
+	}
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, "value")
+	}
+
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(message{"test", m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded message
+	err = gob.NewDecoder(&buf).Decode(&decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Name != "test" || decoded.Entries.Size() != m.Size() {
+		t.Fail()
+	}
+	if !decoded.Entries.Equal(m) {
+		t.Fail()
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	m := Map{}.Set("b", []int{1, 2}).Set("a", "text").Set("c", nil)
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"a":"text","b":[1,2],"c":null}` {
+		t.Fail()
+	}
+
+	data, err = json.Marshal(Map{})
+	if err != nil || string(data) != "{}" {
+		t.Fail()
+	}
+}
+
+func TestMarshalJSONNonStringKeyFails(t *testing.T) {
+	m := Map{}.Set("a", 1).Set(2, 2)
+	_, err := json.Marshal(m)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	m := Map{}.Set("stale", true)
+	err := json.Unmarshal([]byte(`{"a":"text","b":[1,2],"c":null,"d":1.5}`), &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Size() != 4 || m.Has("stale") {
+		t.Fail()
+	}
+	if v, _ := m.Get("a"); v != "text" {
+		t.Fail()
+	}
+	if v, _ := m.Get("b"); len(v.([]interface{})) != 2 {
+		t.Fail()
+	}
+	if v, ok := m.Get("c"); !ok || v != nil {
+		t.Fail()
+	}
+	if v, _ := m.Get("d"); v != 1.5 {
+		t.Fail()
+	}
+}
+
+func TestUnmarshalJSONInvalid(t *testing.T) {
+	for _, data := range []string{`{"a":`, `[1, 2]`, `"text"`, `1`} {
+		var m Map
+		if err := m.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("expected error for %s", data)
+		}
+	}
+}
+
+func TestUnmarshalJSONNull(t *testing.T) {
+	var holder struct {
+		M Map
+	}
+	holder.M = holder.M.Set("kept", true)
+	err := json.Unmarshal([]byte(`{"M": null}`), &holder)
+	if err != nil || holder.M.Size() != 1 || !holder.M.Has("kept") {
+		t.Fail()
+	}
+}
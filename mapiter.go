@@ -0,0 +1,20 @@
+//go:build go1.23
+// +build go1.23
+
+package immutable
+
+import "iter"
+
+// All returns a sequence of all entries in the map, for use with
+// range over func:
+//
+//	for key, value := range m.All() {
+//		...
+//	}
+//
+// The order is the same as for Range.
+func (m Map) All() iter.Seq2[interface{}, interface{}] {
+	return func(yield func(key, value interface{}) bool) {
+		m.root.visit(yield)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build go1.23
+// +build go1.23
+
+package immutable
+
+import "testing"
+
+func TestAll(t *testing.T) {
+	var m Map
+	for i := 0; i < 100; i++ {
+		m = m.Set(i, i*2)
+	}
+	visited := 0
+	for key, value := range m.All() {
+		if value != key.(int)*2 {
+			t.Fail()
+		}
+		visited++
+	}
+	if visited != 100 {
+		t.Fail()
+	}
+
+	visited = 0
+	for range m.All() {
+		visited++
+		if visited == 10 {
+			break
+		}
+	}
+	if visited != 10 {
+		t.Fail()
+	}
+
+	for range (Map{}).All() {
+		t.Fail()
+	}
+}
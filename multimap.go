@@ -0,0 +1,83 @@
+package immutable
+
+// MultiMap is an immutable map from keys to sets of values,
+// stored as a Vector per key.
+// Adding a value that is already stored for a key has no effect,
+// and values are compared using ==.
+//
+// The zero MultiMap is empty and ready for use.
+type MultiMap struct {
+	entries Map
+}
+
+// Add adds a value to the values stored for a key and returns
+// the updated MultiMap.
+func (mm MultiMap) Add(key, value interface{}) MultiMap {
+	values := mm.Get(key)
+	if vectorIndex(values, value) >= 0 {
+		return mm
+	}
+	return MultiMap{
+		entries: mm.entries.Set(key, values.Append(value)),
+	}
+}
+
+// Get returns the values stored for a key, in insertion order.
+// If no values are stored, an empty Vector is returned.
+func (mm MultiMap) Get(key interface{}) Vector {
+	values, ok := mm.entries.Get(key)
+	if !ok {
+		return Vector{}
+	}
+	return values.(Vector)
+}
+
+// Remove returns a MultiMap without the given value for a key.
+// Keys that have no values left are removed.
+// If the value is not stored for the key, the original MultiMap
+// is returned.
+func (mm MultiMap) Remove(key, value interface{}) MultiMap {
+	values := mm.Get(key)
+	index := vectorIndex(values, value)
+	if index < 0 {
+		return mm
+	}
+	if values.Size() == 1 {
+		return MultiMap{
+			entries: mm.entries.Delete(key),
+		}
+	}
+
+	remaining := NewVectorBuilder(values.Size() - 1)
+	r := values.Elements()
+	for i := 0; r.Next(); i++ {
+		if i != index {
+			remaining.Append(r.Get())
+		}
+	}
+	return MultiMap{
+		entries: mm.entries.Set(key, remaining.Build()),
+	}
+}
+
+// Range calls visitor for each key and its values.
+// If visitor returns false, the iteration stops.
+func (mm MultiMap) Range(visitor func(key interface{}, values Vector) bool) {
+	mm.entries.Range(func(key, value interface{}) bool {
+		return visitor(key, value.(Vector))
+	})
+}
+
+// Size returns the number of keys in the MultiMap.
+func (mm MultiMap) Size() uint32 {
+	return mm.entries.Size()
+}
+
+func vectorIndex(v Vector, value interface{}) int {
+	for i := uint32(0); i < v.Size(); i++ {
+		if v.Get(i) == value {
+			return int(i)
+		}
+	}
+	return -1
+}
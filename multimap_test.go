@@ -0,0 +1,67 @@
+package immutable
+
+import "testing"
+
+func TestMultiMapGetEmpty(t *testing.T) {
+	var mm MultiMap
+	if mm.Get("nothing").Size() != 0 {
+		t.Fail()
+	}
+}
+
+func TestMultiMapAddMany(t *testing.T) {
+	var mm MultiMap
+	mm = mm.Add("a", 1)
+	mm = mm.Add("a", 2)
+	mm = mm.Add("a", 2)
+	mm = mm.Add("b", 3)
+
+	a := mm.Get("a")
+	if a.Size() != 2 || a.Get(0) != 1 || a.Get(1) != 2 {
+		t.Fail()
+	}
+	if mm.Get("b").Size() != 1 {
+		t.Fail()
+	}
+	if mm.Size() != 2 {
+		t.Fail()
+	}
+}
+
+func TestMultiMapRemove(t *testing.T) {
+	var mm MultiMap
+	mm = mm.Add("a", 1)
+	mm = mm.Add("a", 2)
+	mm = mm.Add("a", 3)
+
+	removed := mm.Remove("a", 2)
+	a := removed.Get("a")
+	if a.Size() != 2 || a.Get(0) != 1 || a.Get(1) != 3 {
+		t.Fail()
+	}
+	if mm.Get("a").Size() != 3 {
+		t.Fail()
+	}
+
+	removed = removed.Remove("a", 1).Remove("a", 3)
+	if removed.Size() != 0 {
+		t.Fail()
+	}
+
+	if mm.Remove("a", 4).Get("a").Size() != 3 {
+		t.Fail()
+	}
+}
+
+func TestMultiMapRange(t *testing.T) {
+	var mm MultiMap
+	mm = mm.Add(1, "x").Add(1, "y").Add(2, "z")
+	values := 0
+	mm.Range(func(key interface{}, v Vector) bool {
+		values += int(v.Size())
+		return true
+	})
+	if values != 3 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,129 @@
+package immutable
+
+// Set is an immutable set with copy-on-write semantics, based on Map.
+// Adding to or removing from the set returns a new set instance.
+// Set elements must be valid map keys.
+//
+// The zero Set is empty and ready for use.
+type Set struct {
+	elements Map
+}
+
+var present = struct{}{}
+
+// Add adds an element to the set and returns the updated set.
+func (s Set) Add(element interface{}) Set {
+	return Set{
+		elements: s.elements.Set(element, present),
+	}
+}
+
+// Remove returns a set without the given element.
+// If the element is not in the set, the original set is returned.
+func (s Set) Remove(element interface{}) Set {
+	return Set{
+		elements: s.elements.Delete(element),
+	}
+}
+
+// Has returns true if the element is in the set.
+func (s Set) Has(element interface{}) bool {
+	_, ok := s.elements.Get(element)
+	return ok
+}
+
+// Size returns the number of elements in the set.
+func (s Set) Size() uint32 {
+	return s.elements.Size()
+}
+
+// Range calls visitor for each element in the set.
+// If visitor returns false, the iteration stops.
+func (s Set) Range(visitor func(element interface{}) bool) {
+	s.elements.Range(func(key, value interface{}) bool {
+		return visitor(key)
+	})
+}
+
+// Union returns a set with the elements of both sets.
+// The elements of the smaller set are added to the larger set, so
+// the result has the map configuration of the larger set.
+func (s Set) Union(other Set) Set {
+	smaller, larger := s, other
+	if smaller.Size() > larger.Size() {
+		smaller, larger = larger, smaller
+	}
+
+	builder := larger.elements.Builder()
+	smaller.Range(func(element interface{}) bool {
+		builder.Set(element, present)
+		return true
+	})
+	return Set{
+		elements: builder.Build(),
+	}
+}
+
+// Intersection returns a set with the elements present in both sets.
+// The result has the map configuration of s.
+func (s Set) Intersection(other Set) Set {
+	smaller, larger := s, other
+	if smaller.Size() > larger.Size() {
+		smaller, larger = larger, smaller
+	}
+
+	builder := s.elements.empty().Builder()
+	smaller.Range(func(element interface{}) bool {
+		if larger.Has(element) {
+			builder.Set(element, present)
+		}
+		return true
+	})
+	return Set{
+		elements: builder.Build(),
+	}
+}
+
+// Difference returns a set with the elements that are not present
+// in other.
+// The result has the map configuration of s.
+func (s Set) Difference(other Set) Set {
+	builder := s.elements.empty().Builder()
+	s.Range(func(element interface{}) bool {
+		if !other.Has(element) {
+			builder.Set(element, present)
+		}
+		return true
+	})
+	return Set{
+		elements: builder.Build(),
+	}
+}
+
+// KeySet returns a set of the map keys.
+// The set has the configuration of m, so keys are looked up using
+// the same hasher and key normalizer.
+func (m Map) KeySet() Set {
+	builder := m.empty().Builder()
+	m.Range(func(key, value interface{}) bool {
+		builder.Set(key, present)
+		return true
+	})
+	return Set{
+		elements: builder.Build(),
+	}
+}
+
+// ElementSet returns a set of the distinct vector elements.
+// Elements must be valid map keys, otherwise ElementSet panics.
+// The set stores its elements in a map configured using options.
+func (v Vector) ElementSet(options ...MapOption) Set {
+	builder := NewMap(options...).Builder()
+	r := v.Elements()
+	for r.Next() {
+		builder.Set(r.Get(), present)
+	}
+	return Set{
+		elements: builder.Build(),
+	}
+}
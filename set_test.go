@@ -0,0 +1,132 @@
+package immutable
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetAddRemove(t *testing.T) {
+	var s Set
+	if s.Has(1) || s.Size() != 0 {
+		t.Fail()
+	}
+	s = s.Add(1).Add(2).Add(2)
+	if !s.Has(1) || !s.Has(2) || s.Size() != 2 {
+		t.Fail()
+	}
+	removed := s.Remove(1)
+	if removed.Has(1) || removed.Size() != 1 || !s.Has(1) {
+		t.Fail()
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	var a, b Set
+	for i := 0; i < 10; i++ {
+		a = a.Add(i)
+	}
+	for i := 5; i < 20; i++ {
+		b = b.Add(i)
+	}
+	if a.Union(b).Size() != 20 {
+		t.Fail()
+	}
+	intersection := a.Intersection(b)
+	if intersection.Size() != 5 || !intersection.Has(5) || intersection.Has(4) {
+		t.Fail()
+	}
+	difference := a.Difference(b)
+	if difference.Size() != 5 || !difference.Has(4) || difference.Has(5) {
+		t.Fail()
+	}
+	if a.Size() != 10 || b.Size() != 15 {
+		t.Fail()
+	}
+}
+
+func TestSetRange(t *testing.T) {
+	s := Set{}.Add("a").Add("b")
+	visited := 0
+	s.Range(func(element interface{}) bool {
+		if !s.Has(element) {
+			t.Fail()
+		}
+		visited++
+		return true
+	})
+	if visited != 2 {
+		t.Fail()
+	}
+}
+
+func TestKeySet(t *testing.T) {
+	var a, b Map
+	for i := 0; i < 100; i++ {
+		a = a.Set(i, i)
+	}
+	for i := 0; i < 50; i++ {
+		b = b.Set(i, "b")
+	}
+	keys := a.KeySet()
+	if keys.Size() != 100 {
+		t.Fail()
+	}
+	a.Range(func(key, value interface{}) bool {
+		if !keys.Has(key) {
+			t.Fail()
+		}
+		return true
+	})
+	if keys.Difference(b.KeySet()).Size() != 50 {
+		t.Fail()
+	}
+}
+
+func TestKeySetKeepsNormalizer(t *testing.T) {
+	m := NewMap(WithKeyNormalizer(func(key interface{}) interface{} {
+		return strings.ToLower(key.(string))
+	}))
+	keys := m.Set("Key", 1).KeySet()
+	if !keys.Has("KEY") || !keys.Add("key").Has("Key") || keys.Add("kEY").Size() != 1 {
+		t.Fail()
+	}
+}
+
+func TestSetOperationsKeepNormalizer(t *testing.T) {
+	normalizer := WithKeyNormalizer(func(key interface{}) interface{} {
+		return strings.ToLower(key.(string))
+	})
+	a := NewMap(normalizer).Set("a", 1).Set("b", 2).Set("c", 3).KeySet()
+	b := NewMap(normalizer).Set("B", 1).Set("C", 2).Set("D", 3).Set("E", 4).KeySet()
+	c := Vector{}.Append("A").Append("c").ElementSet(normalizer)
+
+	intersection := a.Intersection(b)
+	if intersection.Size() != 2 || !intersection.Has("B") || !intersection.Add("b").Has("C") {
+		t.Fail()
+	}
+	difference := a.Difference(b)
+	if difference.Size() != 1 || !difference.Has("A") || difference.Add("a").Size() != 1 {
+		t.Fail()
+	}
+	if !c.Has("a") || !c.Has("C") || !a.Intersection(c).Has("A") {
+		t.Fail()
+	}
+	if !a.Union(b).Has("A") || !b.Union(a).Has("e") {
+		t.Fail()
+	}
+}
+
+func TestElementSet(t *testing.T) {
+	var v Vector
+	for i := 0; i < 100; i++ {
+		v = v.Append(i % 7)
+	}
+	v = v.Append("last")
+	elements := v.Slice(1, v.Size()).ElementSet()
+	if elements.Size() != 8 {
+		t.Fail()
+	}
+	if !elements.Has(0) || !elements.Has("last") || elements.Has(7) {
+		t.Fail()
+	}
+}
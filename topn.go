@@ -0,0 +1,61 @@
+package immutable
+
+import (
+	"container/heap"
+)
+
+// topN collects the n largest items added to it, using a bounded
+// min-heap.
+type topN struct {
+	n     int
+	items []interface{}
+	less  func(a, b interface{}) bool
+}
+
+func newTopN(n uint32, less func(a, b interface{}) bool) *topN {
+	return &topN{
+		n:    int(n),
+		less: less,
+	}
+}
+
+func (t *topN) add(item interface{}) {
+	if len(t.items) < t.n {
+		heap.Push(t, item)
+	} else if t.n > 0 && t.less(t.items[0], item) {
+		t.items[0] = item
+		heap.Fix(t, 0)
+	}
+}
+
+// sorted returns the collected items, largest first.
+func (t *topN) sorted() []interface{} {
+	sorted := make([]interface{}, len(t.items))
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(t)
+	}
+	return sorted
+}
+
+func (t *topN) Len() int {
+	return len(t.items)
+}
+
+func (t *topN) Less(i, j int) bool {
+	return t.less(t.items[i], t.items[j])
+}
+
+func (t *topN) Swap(i, j int) {
+	t.items[i], t.items[j] = t.items[j], t.items[i]
+}
+
+func (t *topN) Push(item interface{}) {
+	t.items = append(t.items, item)
+}
+
+func (t *topN) Pop() interface{} {
+	last := len(t.items) - 1
+	item := t.items[last]
+	t.items = t.items[:last]
+	return item
+}
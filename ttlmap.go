@@ -0,0 +1,75 @@
+package immutable
+
+import (
+	"time"
+)
+
+// TTLMap is an immutable map where each entry has an expiry time.
+// Expired entries are not returned by Get, but stay in the map
+// until removed by Prune.
+//
+// The zero TTLMap is empty and ready for use.
+type TTLMap struct {
+	entries Map
+}
+
+type ttlEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// Set adds an entry that expires at the given time and returns
+// the updated map.
+func (tm TTLMap) Set(key, value interface{}, expires time.Time) TTLMap {
+	return TTLMap{
+		entries: tm.entries.Set(key, ttlEntry{value, expires}),
+	}
+}
+
+// Get retrieves a value from the map, if it has not expired at
+// the time given by now.
+func (tm TTLMap) Get(key interface{}, now time.Time) (interface{}, bool) {
+	entry, ok := tm.entries.Get(key)
+	if !ok {
+		return nil, false
+	}
+	e := entry.(ttlEntry)
+	if !now.Before(e.expires) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Delete returns a map without entries matching the key.
+func (tm TTLMap) Delete(key interface{}) TTLMap {
+	return TTLMap{
+		entries: tm.entries.Delete(key),
+	}
+}
+
+// Prune returns a map without the entries that have expired at
+// the time given by now.
+// If no entries have expired, the original map is returned.
+func (tm TTLMap) Prune(now time.Time) TTLMap {
+	var expired []interface{}
+	tm.entries.Range(func(key, value interface{}) bool {
+		if !now.Before(value.(ttlEntry).expires) {
+			expired = append(expired, key)
+		}
+		return true
+	})
+
+	entries := tm.entries
+	for _, key := range expired {
+		entries = entries.Delete(key)
+	}
+	return TTLMap{
+		entries: entries,
+	}
+}
+
+// Size returns the number of entries in the map, including
+// expired entries that have not been pruned.
+func (tm TTLMap) Size() uint32 {
+	return tm.entries.Size()
+}
@@ -0,0 +1,44 @@
+package immutable
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLMapGetBeforeExpiry(t *testing.T) {
+	now := time.Now()
+	var tm TTLMap
+	tm = tm.Set("key", 42, now.Add(time.Minute))
+	v, ok := tm.Get("key", now)
+	if !ok || v != 42 {
+		t.Fail()
+	}
+}
+
+func TestTTLMapGetAfterExpiry(t *testing.T) {
+	now := time.Now()
+	var tm TTLMap
+	tm = tm.Set("key", 42, now.Add(time.Minute))
+	v, ok := tm.Get("key", now.Add(time.Minute))
+	if ok || v != nil {
+		t.Fail()
+	}
+}
+
+func TestTTLMapPrune(t *testing.T) {
+	now := time.Now()
+	var tm TTLMap
+	tm = tm.Set("short", 1, now.Add(time.Second))
+	tm = tm.Set("long", 2, now.Add(time.Hour))
+
+	pruned := tm.Prune(now.Add(time.Minute))
+	if pruned.Size() != 1 {
+		t.Fail()
+	}
+	if _, ok := pruned.Get("long", now); !ok {
+		t.Fail()
+	}
+	if tm.Size() != 2 {
+		t.Fail()
+	}
+}
@@ -1,5 +1,23 @@
 package immutable
 
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ErrOutOfBounds is returned by the error returning vector access
+// methods when accessing an index outside the vector.
+var ErrOutOfBounds = errors.New("immutable: out of bounds vector access")
+
 // Vector is an immutable vector with copy-on-write semantics.
 // Modifying the vector returns a new vector instance.
 // Since the vector is immutable, it is safe to use from
@@ -21,12 +39,37 @@ type Vector struct {
 	// offset into storage structure, for slicing
 	offset uint32
 	// storage root
-	root *vectorNode
+	root *vectorRoot
 }
 
 type vectorNode struct {
 	values   []interface{}
 	children []*vectorNode
+	owner    *owner
+}
+
+// vectorRoot is the root node of a storage structure, caching
+// fingerprints of the vectors sharing the root.
+type vectorRoot struct {
+	vectorNode
+	// cached *vectorFingerprints
+	fingerprints unsafe.Pointer
+}
+
+// fingerprintSlots is the number of fingerprints cached per root,
+// letting a vector and a few of its slices share a root without
+// evicting each other.
+const fingerprintSlots = 4
+
+// vectorFingerprints holds the most recently computed fingerprints
+// of a root, first one first. It is never modified once cached.
+type vectorFingerprints [fingerprintSlots]vectorFingerprint
+
+type vectorFingerprint struct {
+	cached bool
+	size   uint32
+	offset uint32
+	value  uint32
 }
 
 const (
@@ -42,26 +85,25 @@ func (v Vector) Set(index uint32, value interface{}) Vector {
 	if index >= v.size {
 		panic("Out of bounds vector access")
 	}
+	return v.set(index, value, nil)
+}
 
+// set sets the element at the given index, modifying nodes that
+// belong to owner in place and copying all others.
+func (v Vector) set(index uint32, value interface{}, owner *owner) Vector {
 	index += v.offset
 
-	src := v.root
-	nodeIndex := index
-
-	newRoot := &vectorNode{}
-	dst := newRoot
+	newRoot := v.root.editable(owner)
+	dst := &newRoot.vectorNode
 
 	for level := uint32(1); level < v.depth; level++ {
 		shifts := (v.depth - level) * bucketBits
-		nodeIndex = (index >> shifts) & bucketMask
+		nodeIndex := (index >> shifts) & bucketMask
 
-		dst.children = make([]*vectorNode, bucketSize)
-		if src != nil {
-			copy(dst.children, src.children)
-			src = src.children[nodeIndex]
+		if dst.children == nil {
+			dst.children = make([]*vectorNode, bucketSize)
 		}
-
-		nextNode := &vectorNode{}
+		nextNode := dst.children[nodeIndex].editable(owner)
 		dst.children[nodeIndex] = nextNode
 
 		dst = nextNode
@@ -70,9 +112,6 @@ func (v Vector) Set(index uint32, value interface{}) Vector {
 	if dst.values == nil {
 		dst.values = make([]interface{}, bucketSize)
 	}
-	if src != nil {
-		copy(dst.values, src.values)
-	}
 	dst.values[index&bucketMask] = value
 
 	return Vector{
@@ -84,6 +123,65 @@ func (v Vector) Set(index uint32, value interface{}) Vector {
 	}
 }
 
+// editable returns the node itself if it belongs to owner,
+// otherwise a copy belonging to owner.
+// A nil node results in a new, empty node.
+func (n *vectorNode) editable(owner *owner) *vectorNode {
+	if owner != nil && n != nil && n.owner == owner {
+		return n
+	}
+	copied := &vectorNode{}
+	n.copyTo(copied, owner)
+	return copied
+}
+
+// editable returns the root itself if it belongs to owner,
+// otherwise a copy belonging to owner, without cached fingerprints.
+// A nil root results in a new, empty root.
+func (r *vectorRoot) editable(owner *owner) *vectorRoot {
+	if owner != nil && r != nil && r.owner == owner {
+		return r
+	}
+	copied := &vectorRoot{}
+	r.node().copyTo(&copied.vectorNode, owner)
+	return copied
+}
+
+// node returns the storage node of the root, or nil for a nil root.
+func (r *vectorRoot) node() *vectorNode {
+	if r == nil {
+		return nil
+	}
+	return &r.vectorNode
+}
+
+// copyTo makes copied a copy of the node, belonging to owner.
+// Copying a nil node results in an empty node.
+func (n *vectorNode) copyTo(copied *vectorNode, owner *owner) {
+	countVectorNodeAlloc()
+	copied.owner = owner
+	if n == nil {
+		return
+	}
+	if n.children != nil {
+		copied.children = make([]*vectorNode, bucketSize)
+		copy(copied.children, n.children)
+	}
+	if n.values != nil {
+		copied.values = make([]interface{}, bucketSize)
+		copy(copied.values, n.values)
+	}
+}
+
+// SetSafe works like Set, but returns ErrOutOfBounds instead of
+// panicking on out of bounds access.
+func (v Vector) SetSafe(index uint32, value interface{}) (Vector, error) {
+	if index >= v.size {
+		return v, ErrOutOfBounds
+	}
+	return v.set(index, value, nil), nil
+}
+
 // Get returns the element at the given index.
 // Out of bounds access causes panic.
 func (v Vector) Get(index uint32) interface{} {
@@ -93,22 +191,37 @@ func (v Vector) Get(index uint32) interface{} {
 
 	index += v.offset
 
-	node := v.root
-	nodeIndex := index
+	node := v.leaf(index)
+	if node == nil || node.values == nil {
+		return nil
+	}
+	return node.values[index&bucketMask]
+}
+
+// GetSafe works like Get, but returns ErrOutOfBounds instead of
+// panicking on out of bounds access.
+func (v Vector) GetSafe(index uint32) (interface{}, error) {
+	if index >= v.size {
+		return nil, ErrOutOfBounds
+	}
+	return v.Get(index), nil
+}
+
+// leaf returns the leaf node holding the given storage index,
+// or nil if there is no such node.
+func (v Vector) leaf(index uint32) *vectorNode {
+	node := v.root.node()
 
 	for level := uint32(1); level < v.depth; level++ {
-		shifts := (v.depth - level) * bucketBits
-		nodeIndex = (index >> shifts) & bucketMask
-		node = node.children[nodeIndex]
 		if node == nil {
 			return nil
 		}
+		shifts := (v.depth - level) * bucketBits
+		nodeIndex := (index >> shifts) & bucketMask
+		node = node.children[nodeIndex]
 	}
 
-	if node.values == nil {
-		return nil
-	}
-	return node.values[index&bucketMask]
+	return node
 }
 
 // Append adds an element and returns the updated Vector.
@@ -117,48 +230,147 @@ func (v Vector) Append(value interface{}) Vector {
 	return appended.Set(v.size, value)
 }
 
+// Concat returns a vector with the elements of other appended.
+func (v Vector) Concat(other Vector) Vector {
+	return ConcatVectors(v, other)
+}
+
+// ConcatVectors returns a vector with the elements of all given
+// vectors, in order.
+// All elements are appended using a single builder, which is
+// cheaper than repeated calls to Concat.
+func ConcatVectors(vs ...Vector) Vector {
+	if len(vs) == 0 {
+		return Vector{}
+	}
+
+	builder := vs[0].Builder()
+	for _, v := range vs[1:] {
+		r := v.Elements()
+		for r.Next() {
+			builder.Append(r.Get())
+		}
+	}
+	return builder.Build()
+}
+
+// Prepend adds an element first and returns the updated Vector.
+// Prepending is cheap when there is room reserved before the first
+// element, see WithLeadingCapacity. Otherwise, room is reserved
+// by copying the elements to new storage.
+func (v Vector) Prepend(value interface{}) Vector {
+	if v.offset == 0 {
+		reserved := v.size
+		if reserved < bucketSize {
+			reserved = bucketSize
+		}
+		v = v.WithLeadingCapacity(reserved)
+	}
+
+	prepended := Vector{
+		size:     v.size + 1,
+		capacity: v.capacity,
+		depth:    v.depth,
+		offset:   v.offset - 1,
+		root:     v.root,
+	}
+	return prepended.set(0, value, nil)
+}
+
+// WithLeadingCapacity returns a copy of the vector with room for
+// n elements reserved before the first element, allowing that many
+// cheap calls to Prepend.
+// Indices of the returned vector are unaffected by the reserved room.
+// Growing beyond the maximum capacity of 2^30 elements causes panic.
+func (v Vector) WithLeadingCapacity(n uint32) Vector {
+	if n > math.MaxUint32-v.size {
+		panic("Vector capacity exceeded")
+	}
+	var builder VectorBuilder
+	builder.Resize(n + v.size)
+
+	index := n
+	r := v.Elements()
+	for r.Next() {
+		if value := r.Get(); value != nil {
+			builder.Set(index, value)
+		}
+		index++
+	}
+
+	reserved := builder.Build()
+	reserved.size = v.size
+	reserved.offset = n
+	return reserved
+}
+
 // Resize Grows or shrinks a vector to the given size
 // and returns the resized vector.
 // The vector capacity is not affected unless needed to
 // grow the vector.
 // Allocated but ununsed storage is not affected.
+// Growing beyond the maximum capacity of 2^30 elements causes panic.
 func (v Vector) Resize(size uint32) Vector {
-	offset := v.offset
+	resized := v
+	resized.size = size
 	if size == 0 {
-		offset = 0
+		resized.offset = 0
 	}
+	return resized.reserve(size)
+}
 
-	capacity := v.capacity
-	depth := v.depth
-	root := v.root
+// Grow ensures that the vector capacity is large enough for
+// appending the given number of elements without growing the
+// storage structure, and returns the grown vector.
+// Vector size and elements are not affected.
+// Growing beyond the maximum capacity of 2^30 elements causes panic.
+func (v Vector) Grow(additional uint32) Vector {
+	if uint64(v.size)+uint64(additional) > math.MaxUint32 {
+		panic("Vector capacity exceeded")
+	}
+	return v.reserve(v.size + additional)
+}
 
-	if capacity == 0 && size > 0 {
-		capacity = bucketSize
-		depth = 1
-		root = &vectorNode{}
+// reserve returns the vector with storage grown to hold
+// the given number of elements.
+// Requiring more storage than a uint32 capacity can describe
+// causes panic.
+func (v Vector) reserve(required uint32) Vector {
+	if required == 0 {
+		return v
 	}
 
-	for size > capacity {
-		capacity *= bucketSize
-		depth++
-		root = bumpUp(root)
+	if v.capacity == 0 {
+		v.capacity = bucketSize
+		v.depth = 1
+		countVectorNodeAlloc()
+		v.root = &vectorRoot{}
 	}
 
-	return Vector{
-		size:     size,
-		capacity: capacity,
-		depth:    depth,
-		offset:   offset,
-		root:     root,
+	needed := uint64(v.offset) + uint64(required)
+	for needed > uint64(v.capacity) {
+		if uint64(v.capacity)*uint64(bucketSize) > math.MaxUint32 {
+			panic("Vector capacity exceeded")
+		}
+		v.capacity *= bucketSize
+		v.depth++
+		v.root = bumpUp(v.root)
 	}
+
+	return v
 }
 
-func bumpUp(root *vectorNode) *vectorNode {
-	src := root
-	newRoot := &vectorNode{
-		children: make([]*vectorNode, bucketSize),
-	}
-	newRoot.children[0] = src
+// Capacity returns the number of elements the vector can hold
+// without growing the storage structure.
+func (v Vector) Capacity() uint32 {
+	return v.capacity - v.offset
+}
+
+func bumpUp(root *vectorRoot) *vectorRoot {
+	countVectorNodeAlloc()
+	newRoot := &vectorRoot{}
+	newRoot.children = make([]*vectorNode, bucketSize)
+	newRoot.children[0] = root.node()
 	return newRoot
 }
 
@@ -181,7 +393,7 @@ func (v Vector) Slice(start, end uint32) Vector {
 		size:     end - start,
 		capacity: v.capacity,
 		depth:    v.depth,
-		offset:   start,
+		offset:   v.offset + start,
 		root:     v.root,
 	}
 }
@@ -191,44 +403,565 @@ func (v Vector) Size() uint32 {
 	return v.size
 }
 
+// Map returns a vector with each element replaced by the result
+// of calling fn with the element index and value.
+func (v Vector) Map(fn func(index uint32, value interface{}) interface{}) Vector {
+	var builder VectorBuilder
+	r := v.Elements()
+	for r.Next() {
+		builder.Append(fn(builder.Size(), r.Get()))
+	}
+	return builder.Build()
+}
+
+// MapParallel works like Map, but calls fn concurrently from the
+// given number of worker goroutines, each mapping a consecutive part
+// of the vector.
+// With less than two workers, MapParallel is the same as Map.
+func (v Vector) MapParallel(workers int, fn func(index uint32, value interface{}) interface{}) Vector {
+	if workers <= 1 || v.size < 2 {
+		return v.Map(fn)
+	}
+
+	results := make([]interface{}, v.size)
+	chunkSize := (v.size + uint32(workers) - 1) / uint32(workers)
+
+	var wg sync.WaitGroup
+	for start := uint32(0); start < v.size; start += chunkSize {
+		wg.Add(1)
+		go func(start uint32) {
+			defer wg.Done()
+			index := start
+			r := v.Slice(start, start+chunkSize).Elements()
+			for r.Next() {
+				results[index] = fn(index, r.Get())
+				index++
+			}
+		}(start)
+	}
+	wg.Wait()
+
+	return vectorOf(results)
+}
+
+// MapErr returns a vector with each element replaced by the result
+// of calling fn with the element index and value.
+// If fn returns an error, mapping stops and the elements mapped so
+// far are returned together with the error.
+func (v Vector) MapErr(fn func(index uint32, value interface{}) (interface{}, error)) (Vector, error) {
+	var builder VectorBuilder
+	r := v.Elements()
+	for r.Next() {
+		mapped, err := fn(builder.Size(), r.Get())
+		if err != nil {
+			return builder.Build(), err
+		}
+		builder.Append(mapped)
+	}
+	return builder.Build(), nil
+}
+
+// RangeNonNil calls visitor with the index and value of each
+// non-nil element, in order.
+// If visitor returns false, the iteration stops.
+// Unset parts of sparse vectors are skipped without visiting each
+// index.
+func (v Vector) RangeNonNil(visitor func(index uint32, value interface{}) bool) {
+	end := v.offset + v.size
+	for start := v.offset; start < end; {
+		leafEnd := (start | bucketMask) + 1
+		if leafEnd > end || leafEnd == 0 {
+			leafEnd = end
+		}
+
+		node := v.leaf(start)
+		if node != nil && node.values != nil {
+			for index := start; index < leafEnd; index++ {
+				value := node.values[index&bucketMask]
+				if value != nil && !visitor(index-v.offset, value) {
+					return
+				}
+			}
+		}
+		start = leafEnd
+	}
+}
+
+// RangeContext calls visitor for each element of the vector, in
+// order, and stops early if ctx is done, returning the context error.
+// If visitor returns false, the iteration stops.
+// The context is checked at each storage leaf boundary.
+func (v Vector) RangeContext(ctx context.Context, visitor func(index uint32, value interface{}) bool) error {
+	r := v.Elements()
+	for r.Next() {
+		if r.position == 0 || (r.position+v.offset)&bucketMask == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if !visitor(r.position, r.Get()) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Stream returns a channel receiving all elements of the vector in
+// order, which is closed after the last element.
+// Sending stops and the channel is closed when done is closed.
+// A nil done channel requires the channel to be drained, or the
+// goroutine sending the elements leaks.
+func (v Vector) Stream(done <-chan struct{}) <-chan interface{} {
+	elements := make(chan interface{})
+	go func() {
+		defer close(elements)
+		r := v.Elements()
+		for r.Next() {
+			select {
+			case elements <- r.Get():
+			case <-done:
+				return
+			}
+		}
+	}()
+	return elements
+}
+
+// TopN returns a vector of the n largest elements according to
+// less, largest first.
+func (v Vector) TopN(n uint32, less func(a, b interface{}) bool) Vector {
+	top := newTopN(n, less)
+	r := v.Elements()
+	for r.Next() {
+		top.add(r.Get())
+	}
+
+	return vectorOf(top.sorted())
+}
+
+// Shuffle returns a vector with the elements randomly permuted,
+// with rng as the source of randomness.
+func (v Vector) Shuffle(rng *rand.Rand) Vector {
+	values := v.values()
+	rng.Shuffle(len(values), func(i, j int) {
+		values[i], values[j] = values[j], values[i]
+	})
+	return vectorOf(values)
+}
+
+// values returns the vector elements as a slice.
+func (v Vector) values() []interface{} {
+	values := make([]interface{}, 0, v.size)
+	r := v.Elements()
+	for r.Next() {
+		values = append(values, r.Get())
+	}
+	return values
+}
+
+// vectorOf returns a vector holding the given values.
+func vectorOf(values []interface{}) Vector {
+	var builder VectorBuilder
+	for _, value := range values {
+		builder.Append(value)
+	}
+	return builder.Build()
+}
+
+// EqualPrefix returns true if the first n elements of the vectors
+// are equal, using ==.
+// If any of the vectors has less than n elements, the length of the
+// shorter vector is used instead of n.
+func (v Vector) EqualPrefix(other Vector, n uint32) bool {
+	if n > v.size {
+		n = v.size
+	}
+	if n > other.size {
+		n = other.size
+	}
+
+	a := v.Slice(0, n).Elements()
+	b := other.Slice(0, n).Elements()
+	for a.Next() && b.Next() {
+		if a.Get() != b.Get() {
+			return false
+		}
+	}
+	return true
+}
+
+// RunLengthPair is a run of Count equal elements, as produced by
+// RunLengthEncode.
+type RunLengthPair struct {
+	Value interface{}
+	Count uint32
+}
+
+// RunLengthEncode returns a vector of RunLengthPair elements, one for
+// each run of adjacent elements that are equal according to eq.
+func (v Vector) RunLengthEncode(eq func(a, b interface{}) bool) Vector {
+	var builder VectorBuilder
+	var run RunLengthPair
+
+	r := v.Elements()
+	for r.Next() {
+		value := r.Get()
+		if run.Count > 0 && eq(run.Value, value) {
+			run.Count++
+			continue
+		}
+		if run.Count > 0 {
+			builder.Append(run)
+		}
+		run = RunLengthPair{value, 1}
+	}
+	if run.Count > 0 {
+		builder.Append(run)
+	}
+	return builder.Build()
+}
+
+// RunLengthDecode returns the vector encoded by RunLengthEncode.
+// All elements must be of type RunLengthPair.
+func (v Vector) RunLengthDecode() Vector {
+	var builder VectorBuilder
+	r := v.Elements()
+	for r.Next() {
+		run := r.Get().(RunLengthPair)
+		for i := uint32(0); i < run.Count; i++ {
+			builder.Append(run.Value)
+		}
+	}
+	return builder.Build()
+}
+
+// Partition splits the vector into the elements for which pred
+// returns true, and the rest. Elements keep their relative order.
+func (v Vector) Partition(pred func(value interface{}) bool) (matching Vector, rest Vector) {
+	var matchingBuilder, restBuilder VectorBuilder
+	r := v.Elements()
+	for r.Next() {
+		value := r.Get()
+		if pred(value) {
+			matchingBuilder.Append(value)
+		} else {
+			restBuilder.Append(value)
+		}
+	}
+	return matchingBuilder.Build(), restBuilder.Build()
+}
+
+// ToMap returns a map with the elements of the vector as values,
+// keyed by the result of keyFn for each element. If several elements
+// get the same key, the last one wins.
+func (v Vector) ToMap(keyFn func(index uint32, value interface{}) interface{}) Map {
+	var builder MapBuilder
+	r := v.Elements()
+	for r.Next() {
+		value := r.Get()
+		builder.Set(keyFn(r.position, value), value)
+	}
+	return builder.Build()
+}
+
+// CountValues returns a map from each distinct element of the vector
+// to the number of times it occurs, as an int. Elements must be
+// comparable, just like map keys, otherwise CountValues panics.
+func (v Vector) CountValues() Map {
+	var counts MapBuilder
+	r := v.Elements()
+	for r.Next() {
+		countValue(&counts, r.Get())
+	}
+	return counts.Build()
+}
+
+// EqualUnordered returns true if both vectors hold the same elements
+// the same number of times, in any order. Elements are compared
+// through their counts, so they must be comparable, just like map
+// keys, otherwise EqualUnordered panics.
+func (v Vector) EqualUnordered(other Vector) bool {
+	if v.size != other.size {
+		return false
+	}
+	return v.CountValues().Equal(other.CountValues())
+}
+
+// ChunkedReduce splits the vector into consecutive chunks of
+// chunkSize elements and returns a vector of the values produced by
+// reduce for each chunk. The last chunk may be smaller.
+// A zero chunk size causes panic.
+func (v Vector) ChunkedReduce(chunkSize uint32, reduce func(chunk Vector) interface{}) Vector {
+	if chunkSize == 0 {
+		panic("Invalid chunk size")
+	}
+	var builder VectorBuilder
+	size := v.Size()
+	for start := uint32(0); start < size; start += chunkSize {
+		end := start + chunkSize
+		if end > size || end < start {
+			end = size
+		}
+		builder.Append(reduce(v.Slice(start, end)))
+	}
+	return builder.Build()
+}
+
+// DedupAdjacent returns a vector without adjacent duplicates,
+// keeping the first element of each run of elements that are
+// equal according to eq. Elements that are equal, but not
+// adjacent, are kept.
+func (v Vector) DedupAdjacent(eq func(a, b interface{}) bool) Vector {
+	var builder VectorBuilder
+	var previous interface{}
+	r := v.Elements()
+	for r.Next() {
+		value := r.Get()
+		if builder.Size() > 0 && eq(previous, value) {
+			continue
+		}
+		builder.Append(value)
+		previous = value
+	}
+	return builder.Build()
+}
+
+// IsSorted returns true if the elements are in non-decreasing order
+// according to less.
+func (v Vector) IsSorted(less func(a, b interface{}) bool) bool {
+	r := v.Elements()
+	if !r.Next() {
+		return true
+	}
+	previous := r.Get()
+	for r.Next() {
+		current := r.Get()
+		if less(current, previous) {
+			return false
+		}
+		previous = current
+	}
+	return true
+}
+
+// Interpose returns a vector with separator inserted between each
+// pair of adjacent elements.
+func (v Vector) Interpose(separator interface{}) Vector {
+	if v.size < 2 {
+		return v
+	}
+
+	var builder VectorBuilder
+	for i := uint32(0); i < v.size; i++ {
+		if i > 0 {
+			builder.Append(separator)
+		}
+		builder.Append(v.Get(i))
+	}
+	return builder.Build()
+}
+
+// Fingerprint returns a hash of the vector elements, in order.
+// Equal vectors have equal fingerprints within the same process.
+// Elements must be valid map keys.
+//
+// The fingerprint is computed by visiting all elements, and then
+// cached for the vector version. The storage root caches the
+// fingerprints of the last few versions sharing it, such as a
+// vector and its slices.
+func (v Vector) Fingerprint() uint32 {
+	if v.root == nil {
+		return v.computeFingerprint()
+	}
+
+	cached := (*vectorFingerprints)(atomic.LoadPointer(&v.root.fingerprints))
+	if cached != nil {
+		for _, f := range cached {
+			if f.cached && f.size == v.size && f.offset == v.offset {
+				return f.value
+			}
+		}
+	}
+
+	value := v.computeFingerprint()
+	var updated vectorFingerprints
+	updated[0] = vectorFingerprint{
+		cached: true,
+		size:   v.size,
+		offset: v.offset,
+		value:  value,
+	}
+	if cached != nil {
+		copy(updated[1:], cached[:])
+	}
+	atomic.StorePointer(&v.root.fingerprints, unsafe.Pointer(&updated))
+	return value
+}
+
+func (v Vector) computeFingerprint() uint32 {
+	countFingerprint()
+
+	fingerprint := v.size
+	r := v.Elements()
+	for r.Next() {
+		var hash uint32
+		if value := r.Get(); value != nil {
+			hash = hashValue(value)
+		}
+		fingerprint = fingerprint*31 + hash
+	}
+	return fingerprint
+}
+
+// HashKey returns a string built from the elements of the vector,
+// in order, for use as a Go map key. Equal vectors have equal hash
+// keys, and vectors with elements of different types or Go syntax
+// representations have different hash keys.
+//
+// Each call formats all elements, so the cost is proportional to the
+// vector size and element complexity.
+//
+// Negative zero float and complex elements are formatted as positive
+// zero, since they compare equal. Zeros nested within other element
+// values, such as struct fields, are formatted as is.
+// Pointer, channel, function and unsafe.Pointer elements are formatted
+// by address, not by what they point to.
+func (v Vector) HashKey() string {
+	var b strings.Builder
+	r := v.Elements()
+	for r.Next() {
+		element := hashKeyElement(r.Get())
+		fmt.Fprintf(&b, "%d:%s;", len(element), element)
+	}
+	return b.String()
+}
+
+func hashKeyElement(value interface{}) string {
+	value = positiveZero(value)
+	if value != nil {
+		switch reflect.TypeOf(value).Kind() {
+		case reflect.Ptr, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+			return fmt.Sprintf("%T:%#x", value, reflect.ValueOf(value).Pointer())
+		}
+	}
+	return fmt.Sprintf("%T:%#v", value, value)
+}
+
+// positiveZero returns value with negative float zeros replaced by
+// positive zeros.
+func positiveZero(value interface{}) interface{} {
+	switch val := value.(type) {
+	case float32:
+		if val == 0 {
+			return float32(0)
+		}
+	case float64:
+		if val == 0 {
+			return float64(0)
+		}
+	case complex64:
+		return complex(positiveZero(real(val)).(float32), positiveZero(imag(val)).(float32))
+	case complex128:
+		return complex(positiveZero(real(val)).(float64), positiveZero(imag(val)).(float64))
+	}
+	return value
+}
+
+// String returns a readable representation of the vector.
+// Elements implementing fmt.Stringer or encoding.TextMarshaler are
+// rendered using those interfaces.
+func (v Vector) String() string {
+	return v.format(formatElement)
+}
+
+// GoString returns a Go syntax representation of the vector,
+// rendering elements using the %#v verb.
+func (v Vector) GoString() string {
+	return v.format(func(value interface{}) string {
+		return fmt.Sprintf("%#v", value)
+	})
+}
+
+func (v Vector) format(element func(value interface{}) string) string {
+	var b strings.Builder
+	b.WriteString("immutable.Vector{")
+	r := v.Elements()
+	for r.Next() {
+		if r.position > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(element(r.Get()))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// formatElement leaves fmt.Stringer elements to fmt, which handles
+// nil receivers and panicking String methods.
+func formatElement(value interface{}) string {
+	if _, ok := value.(fmt.Stringer); !ok {
+		if val, ok := value.(encoding.TextMarshaler); ok && !isNilPointer(val) {
+			text, err := val.MarshalText()
+			if err == nil {
+				return string(text)
+			}
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func isNilPointer(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// Sample returns a Vector of n elements randomly selected from the
+// vector using reservoir sampling, with rng as the source of
+// randomness.
+// If n is not less than the vector size, the vector is returned
+// unchanged.
+func (v Vector) Sample(n uint32, rng *rand.Rand) Vector {
+	if n >= v.size {
+		return v
+	}
+
+	reservoir := v.Slice(0, n)
+	for i := n; i < v.size; i++ {
+		j := uint32(rng.Int63n(int64(i) + 1))
+		if j < n {
+			reservoir = reservoir.Set(j, v.Get(i))
+		}
+	}
+	return reservoir
+}
+
 type VectorRange struct {
-	vector       Vector
-	position     uint32
-	nodePosition uint32
-	root         *vectorNode
-	node         *vectorNode
+	vector   Vector
+	position uint32
+	started  bool
+	node     *vectorNode
 }
 
 // Next moves to the next element and returns true
 // if there are more elements available.
 func (vr *VectorRange) Next() bool {
-	if vr.root == nil {
-		vr.root = vr.vector.root
-		vr.nodePosition = bucketSize
-	} else {
+	if vr.started {
+		if vr.position == vr.vector.size {
+			return false
+		}
 		vr.position++
-		vr.nodePosition++
 	}
 
 	if vr.position == vr.vector.size {
+		vr.node = nil
 		return false
 	}
 
-	if vr.root != nil && vr.nodePosition == bucketSize {
-		vr.nodePosition = 0
-		node := vr.root
-		depth := vr.vector.depth
-		index := vr.position
-		for level := uint32(1); level < depth; level++ {
-			if node == nil {
-				break
-			}
-			shifts := (depth - level) * bucketBits
-			nodeIndex := (index >> shifts) & bucketMask
-			node = node.children[nodeIndex]
-		}
-		vr.node = node
+	index := vr.position + vr.vector.offset
+	if !vr.started || index&bucketMask == 0 {
+		vr.node = vr.vector.leaf(index)
 	}
+	vr.started = true
 
 	return true
 }
@@ -236,10 +969,63 @@ func (vr *VectorRange) Next() bool {
 // Get returns the element at the current position of the
 // range.
 func (vr *VectorRange) Get() interface{} {
-	if vr.node == nil {
+	if vr.node == nil || vr.node.values == nil {
+		return nil
+	}
+	index := vr.position + vr.vector.offset
+	return vr.node.values[index&bucketMask]
+}
+
+// VectorCursor provides access to vector elements at arbitrary
+// positions. The storage node of the current position is cached,
+// making access to nearby positions cheap.
+type VectorCursor struct {
+	vector Vector
+	index  uint32
+	leaf   uint32
+	cached bool
+	node   *vectorNode
+}
+
+// Cursor returns a cursor for accessing the vector elements,
+// positioned at the first element.
+func (v Vector) Cursor() VectorCursor {
+	return VectorCursor{
+		vector: v,
+	}
+}
+
+// Seek moves the cursor to the given index.
+// Out of bounds access causes panic.
+func (c *VectorCursor) Seek(index uint32) {
+	if index >= c.vector.size {
+		panic("Out of bounds vector access")
+	}
+	c.index = index
+
+	leaf := (index + c.vector.offset) >> bucketBits
+	if !c.cached || leaf != c.leaf {
+		c.node = c.vector.leaf(index + c.vector.offset)
+		c.leaf = leaf
+		c.cached = true
+	}
+}
+
+// Index returns the current cursor position.
+func (c *VectorCursor) Index() uint32 {
+	return c.index
+}
+
+// Get returns the element at the current cursor position.
+// Out of bounds access causes panic.
+func (c *VectorCursor) Get() interface{} {
+	if !c.cached {
+		c.Seek(c.index)
+	}
+	if c.node == nil || c.node.values == nil {
 		return nil
 	}
-	return vr.node.values[vr.nodePosition]
+	return c.node.values[(c.index+c.vector.offset)&bucketMask]
 }
 
 // Elements returns a range for iterating through the vector.
@@ -1,6 +1,12 @@
 package immutable
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -255,6 +261,17 @@ func TestSliceLimitedRanges(t *testing.T) {
 	}
 }
 
+func TestSliceSlice(t *testing.T) {
+	var v Vector
+	for i := 0; i < 100; i++ {
+		v = v.Append(i)
+	}
+	sliced := v.Slice(10, 90).Slice(5, 10)
+	if sliced.Size() != 5 || sliced.Get(0) != 15 {
+		t.Fail()
+	}
+}
+
 func TestRangeEmptyVector(t *testing.T) {
 	var v Vector
 
@@ -264,6 +281,58 @@ func TestRangeEmptyVector(t *testing.T) {
 	}
 }
 
+func TestVectorSafeAccess(t *testing.T) {
+	v := Vector{}.Resize(10)
+	v, err := v.SetSafe(9, "last")
+	if err != nil {
+		t.Fail()
+	}
+	value, err := v.GetSafe(9)
+	if err != nil || value != "last" {
+		t.Fail()
+	}
+
+	unchanged, err := v.SetSafe(10, "outside")
+	if err != ErrOutOfBounds || unchanged.Size() != 10 {
+		t.Fail()
+	}
+	value, err = v.GetSafe(10)
+	if err != ErrOutOfBounds || value != nil {
+		t.Fail()
+	}
+	if _, err := v.Slice(2, 5).GetSafe(3); err != ErrOutOfBounds {
+		t.Fail()
+	}
+}
+
+func TestVectorRangeContext(t *testing.T) {
+	v := Vector{}.Resize(1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	visited := uint32(0)
+	err := v.RangeContext(ctx, func(index uint32, value interface{}) bool {
+		if index != visited {
+			t.Fail()
+		}
+		visited++
+		if visited == 100 {
+			cancel()
+		}
+		return true
+	})
+	if err != context.Canceled || visited < 100 || visited >= 1000 {
+		t.Fail()
+	}
+
+	visited = 0
+	err = v.Slice(3, 500).RangeContext(context.Background(), func(index uint32, value interface{}) bool {
+		visited++
+		return true
+	})
+	if err != nil || visited != 497 {
+		t.Fail()
+	}
+}
+
 func TestVectorRange(t *testing.T) {
 	v := Vector{}.Resize(112)
 	v = v.Set(42, 42)
@@ -282,6 +351,799 @@ func TestVectorRange(t *testing.T) {
 	}
 }
 
+func TestVectorSample(t *testing.T) {
+	v := Vector{}
+	for i := 0; i < 100; i++ {
+		v = v.Append(i)
+	}
+
+	a := v.Sample(10, rand.New(rand.NewSource(4711)))
+	b := v.Sample(10, rand.New(rand.NewSource(4711)))
+	if a.Size() != 10 || b.Size() != 10 {
+		t.Fail()
+	}
+	for i := uint32(0); i < a.Size(); i++ {
+		if a.Get(i) != b.Get(i) {
+			t.Fail()
+		}
+	}
+
+	all := v.Sample(200, rand.New(rand.NewSource(4711)))
+	if all.Size() != v.Size() {
+		t.Fail()
+	}
+}
+
+func TestVectorInterpose(t *testing.T) {
+	var v Vector
+	if v.Interpose(",").Size() != 0 {
+		t.Fail()
+	}
+
+	v = v.Append("a")
+	single := v.Interpose(",")
+	if single.Size() != 1 || single.Get(0) != "a" {
+		t.Fail()
+	}
+
+	v = v.Append("b").Append("c")
+	multi := v.Interpose(",")
+	expected := []string{"a", ",", "b", ",", "c"}
+	if multi.Size() != uint32(len(expected)) {
+		t.Fail()
+	}
+	for i, e := range expected {
+		if multi.Get(uint32(i)) != e {
+			t.Fail()
+		}
+	}
+}
+
+func TestVectorRangeSlice(t *testing.T) {
+	var v Vector
+	for i := 0; i < 200; i++ {
+		v = v.Append(i)
+	}
+	sliced := v.Slice(30, 150)
+	r := sliced.Elements()
+	expected := 30
+	for r.Next() {
+		if r.Get() != expected {
+			t.Fail()
+		}
+		expected++
+	}
+	if expected != 150 {
+		t.Fail()
+	}
+}
+
+func TestVectorRangeUnset(t *testing.T) {
+	v := Vector{}.Resize(100)
+	r := v.Elements()
+	for r.Next() {
+		if r.Get() != nil {
+			t.Fail()
+		}
+	}
+}
+
+func TestVectorMapErr(t *testing.T) {
+	var v Vector
+	for i := 0; i < 100; i++ {
+		v = v.Append(i)
+	}
+	v = v.Slice(10, 100)
+
+	mapped, err := v.MapErr(func(index uint32, value interface{}) (interface{}, error) {
+		return value.(int) * 2, nil
+	})
+	if err != nil || mapped.Size() != 90 {
+		t.Fail()
+	}
+	if mapped.Get(0) != 20 {
+		t.Fail()
+	}
+
+	failure := errors.New("failure")
+	mapped, err = v.MapErr(func(index uint32, value interface{}) (interface{}, error) {
+		if index == 5 {
+			return nil, failure
+		}
+		return value, nil
+	})
+	if err != failure || mapped.Size() != 5 {
+		t.Fail()
+	}
+}
+
+type stringerElement int
+
+func (s stringerElement) String() string {
+	return fmt.Sprintf("element#%d", int(s))
+}
+
+type textElement struct{}
+
+func (textElement) MarshalText() ([]byte, error) {
+	return []byte("text"), nil
+}
+
+type pointerStringer struct{ name string }
+
+func (p *pointerStringer) String() string {
+	return p.name
+}
+
+type pointerText struct{ text string }
+
+func (p *pointerText) MarshalText() ([]byte, error) {
+	return []byte(p.text), nil
+}
+
+func TestVectorString(t *testing.T) {
+	if (Vector{}).String() != "immutable.Vector{}" {
+		t.Fail()
+	}
+	v := Vector{}.Append(1).Append(stringerElement(2)).Append(textElement{})
+	if v.String() != "immutable.Vector{1, element#2, text}" {
+		t.Fail()
+	}
+	if (Vector{}).Append("a").GoString() != `immutable.Vector{"a"}` {
+		t.Fail()
+	}
+	nils := Vector{}.Append((*pointerStringer)(nil)).Append((*pointerText)(nil))
+	if nils.String() != "immutable.Vector{<nil>, <nil>}" {
+		t.Fail()
+	}
+	pointers := Vector{}.Append(&pointerStringer{"name"}).Append(&pointerText{"text"})
+	if pointers.String() != "immutable.Vector{name, text}" {
+		t.Fail()
+	}
+}
+
+func TestVectorResizeSlice(t *testing.T) {
+	const size = bucketSize * bucketSize
+	var v Vector
+	for i := uint32(0); i < size; i++ {
+		v = v.Append(i)
+	}
+	sliced := v.Slice(size-10, size)
+	grown := sliced.Resize(100)
+	grown = grown.Set(99, "last")
+	if grown.Get(0) != size-10 || grown.Get(99) != "last" {
+		t.Fail()
+	}
+}
+
+func TestVectorPrepend(t *testing.T) {
+	var v Vector
+	for i := 0; i < 100; i++ {
+		v = v.Prepend(i)
+	}
+	if v.Size() != 100 {
+		t.Fail()
+	}
+	for i := uint32(0); i < v.Size(); i++ {
+		if v.Get(i) != int(99-i) {
+			t.Fail()
+		}
+	}
+}
+
+func TestVectorWithLeadingCapacity(t *testing.T) {
+	v := Vector{}.Append("last")
+	v = v.WithLeadingCapacity(1000)
+	if v.Size() != 1 || v.Get(0) != "last" {
+		t.Fail()
+	}
+
+	capacity := v.capacity
+	for i := 0; i < 1000; i++ {
+		v = v.Prepend(i)
+		if v.capacity != capacity || v.offset != uint32(999-i) {
+			t.Fail()
+		}
+	}
+	if v.Size() != 1001 {
+		t.Fail()
+	}
+	for i := uint32(0); i < 1000; i++ {
+		if v.Get(i) != int(999-i) {
+			t.Fail()
+		}
+	}
+	if v.Get(1000) != "last" {
+		t.Fail()
+	}
+}
+
+func TestVectorStream(t *testing.T) {
+	var v Vector
+	for i := 0; i < 100; i++ {
+		v = v.Append(i)
+	}
+	expected := 10
+	for e := range v.Slice(10, 100).Stream(nil) {
+		if e != expected {
+			t.Fail()
+		}
+		expected++
+	}
+	if expected != 100 {
+		t.Fail()
+	}
+}
+
+func TestVectorStreamDone(t *testing.T) {
+	var v Vector
+	for i := 0; i < 100; i++ {
+		v = v.Append(i)
+	}
+	done := make(chan struct{})
+	elements := v.Stream(done)
+	<-elements
+	close(done)
+
+	remaining := 0
+	for range elements {
+		remaining++
+	}
+	if remaining >= 99 {
+		t.Fail()
+	}
+}
+
+func TestVectorFingerprint(t *testing.T) {
+	var a, b Vector
+	for i := 0; i < 100; i++ {
+		a = a.Append(i)
+		b = b.Append(i)
+	}
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Fail()
+	}
+	if a.Fingerprint() == a.Set(10, -1).Fingerprint() {
+		t.Fail()
+	}
+}
+
+func TestVectorHashKey(t *testing.T) {
+	var a, b Vector
+	for i := 0; i < 100; i++ {
+		a = a.Append(i)
+		b = b.Append(i)
+	}
+	if a.HashKey() != b.HashKey() || a.HashKey() != a.Slice(0, 100).HashKey() {
+		t.Fail()
+	}
+	keys := map[string]bool{a.HashKey(): true}
+	for _, changed := range []Vector{
+		a.Set(50, 51),
+		a.Set(50, int64(50)),
+		a.Set(50, "50"),
+		a.Append(nil),
+		a.Slice(1, 100),
+		Vector{},
+		Vector{}.Append(""),
+		Vector{}.Append("a;").Append("b"),
+		Vector{}.Append("a").Append(";b"),
+	} {
+		key := changed.HashKey()
+		if keys[key] {
+			t.Fail()
+		}
+		keys[key] = true
+	}
+}
+
+func TestVectorHashKeyNegativeZero(t *testing.T) {
+	negative := math.Copysign(0, -1)
+	for _, pair := range [][2]interface{}{
+		{0.0, negative},
+		{float32(0), float32(negative)},
+		{complex(0, 0), complex(negative, negative)},
+		{complex64(complex(0, 0)), complex64(complex(negative, negative))},
+	} {
+		a := Vector{}.Append(pair[0])
+		b := Vector{}.Append(pair[1])
+		if !a.EqualPrefix(b, 1) || a.HashKey() != b.HashKey() {
+			t.Fail()
+		}
+	}
+	if (Vector{}).Append(0.0).HashKey() == (Vector{}).Append(float32(0)).HashKey() {
+		t.Fail()
+	}
+}
+
+func TestVectorHashKeyPointers(t *testing.T) {
+	type point struct{ X, Y int }
+	a, b := &point{1, 2}, &point{1, 2}
+	v := Vector{}.Append(a)
+	key := v.HashKey()
+	if key == (Vector{}).Append(b).HashKey() || key != (Vector{}).Append(a).HashKey() {
+		t.Fail()
+	}
+	a.X = 3
+	if v.HashKey() != key {
+		t.Fail()
+	}
+	if (Vector{}).Append(make(chan int)).HashKey() == (Vector{}).Append(make(chan int)).HashKey() {
+		t.Fail()
+	}
+}
+
+func TestVectorTopN(t *testing.T) {
+	var v Vector
+	for _, value := range []int{-1, 5, 3, 17, 4, 2, 11, 8, 0, 9} {
+		v = v.Append(value)
+	}
+	less := func(a, b interface{}) bool {
+		return a.(int) < b.(int)
+	}
+
+	top := v.Slice(1, 10).TopN(3, less)
+	if top.Size() != 3 || top.Get(0) != 17 || top.Get(1) != 11 || top.Get(2) != 9 {
+		t.Fail()
+	}
+	if v.Slice(0, 2).TopN(3, less).Size() != 2 {
+		t.Fail()
+	}
+	if v.TopN(0, less).Size() != 0 {
+		t.Fail()
+	}
+}
+
+func TestVectorRangeNonNil(t *testing.T) {
+	v := Vector{}.Resize(10000)
+	set := []uint32{3, 40, 41, 5000, 9999}
+	for _, index := range set {
+		v = v.Set(index, index)
+	}
+
+	var visited []uint32
+	v.Slice(4, 10000).RangeNonNil(func(index uint32, value interface{}) bool {
+		if value != index+4 {
+			t.Fail()
+		}
+		visited = append(visited, index)
+		return true
+	})
+	if len(visited) != 4 || visited[0] != 36 || visited[3] != 9995 {
+		t.Fail()
+	}
+
+	count := 0
+	v.RangeNonNil(func(index uint32, value interface{}) bool {
+		count++
+		return count < 2
+	})
+	if count != 2 {
+		t.Fail()
+	}
+}
+
+func TestVectorMap(t *testing.T) {
+	var v Vector
+	for i := 0; i < 100; i++ {
+		v = v.Append(i)
+	}
+	mapped := v.Slice(50, 100).Map(func(index uint32, value interface{}) interface{} {
+		return int(index) + value.(int)
+	})
+	if mapped.Size() != 50 || mapped.Get(0) != 50 || mapped.Get(49) != 148 {
+		t.Fail()
+	}
+}
+
+func TestVectorMapParallel(t *testing.T) {
+	var v Vector
+	for i := 0; i < 1000; i++ {
+		v = v.Append(i)
+	}
+	square := func(index uint32, value interface{}) interface{} {
+		return value.(int) * value.(int)
+	}
+	for _, workers := range []int{0, 1, 3, 8, 2000} {
+		mapped := v.MapParallel(workers, square)
+		if mapped.Size() != v.Size() {
+			t.Fail()
+		}
+		for i := uint32(0); i < mapped.Size(); i++ {
+			if mapped.Get(i) != int(i*i) {
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestVectorShuffle(t *testing.T) {
+	var v Vector
+	for i := 0; i < 100; i++ {
+		v = v.Append(i)
+	}
+	v = v.Slice(10, 100)
+
+	a := v.Shuffle(rand.New(rand.NewSource(4711)))
+	b := v.Shuffle(rand.New(rand.NewSource(4711)))
+	if a.Size() != v.Size() {
+		t.Fail()
+	}
+
+	seen := map[interface{}]bool{}
+	moved := 0
+	for i := uint32(0); i < a.Size(); i++ {
+		if a.Get(i) != b.Get(i) {
+			t.Fail()
+		}
+		if a.Get(i) != v.Get(i) {
+			moved++
+		}
+		seen[a.Get(i)] = true
+	}
+	for i := uint32(0); i < v.Size(); i++ {
+		if !seen[v.Get(i)] {
+			t.Fail()
+		}
+	}
+	if moved == 0 {
+		t.Fail()
+	}
+}
+
+func TestVectorEqualPrefix(t *testing.T) {
+	var a, b Vector
+	for i := 0; i < 100; i++ {
+		a = a.Append(i)
+		b = b.Append(i + 50)
+	}
+	b = b.Set(20, -1)
+
+	shifted := a.Slice(50, 100)
+	if !shifted.EqualPrefix(b, 20) {
+		t.Fail()
+	}
+	if shifted.EqualPrefix(b, 21) {
+		t.Fail()
+	}
+	if !a.Slice(0, 10).EqualPrefix(a, 1000) {
+		t.Fail()
+	}
+	if a.Slice(1, 10).EqualPrefix(a, 1000) {
+		t.Fail()
+	}
+	if !a.EqualPrefix(Vector{}, 5) {
+		t.Fail()
+	}
+}
+
+func TestVectorRunLengthEncode(t *testing.T) {
+	var v Vector
+	v = v.Append("skipped")
+	for i := 0; i < 100; i++ {
+		v = v.Append("a")
+	}
+	v = v.Append("b")
+	for i := 0; i < 50; i++ {
+		v = v.Append("a")
+	}
+	v = v.Slice(1, v.Size())
+
+	eq := func(a, b interface{}) bool {
+		return a == b
+	}
+	encoded := v.RunLengthEncode(eq)
+	if encoded.Size() != 3 {
+		t.Fail()
+	}
+	if encoded.Get(0) != (RunLengthPair{"a", 100}) {
+		t.Fail()
+	}
+	if encoded.Get(1) != (RunLengthPair{"b", 1}) {
+		t.Fail()
+	}
+
+	decoded := encoded.RunLengthDecode()
+	if decoded.Size() != v.Size() || !decoded.EqualPrefix(v, v.Size()) {
+		t.Fail()
+	}
+	if (Vector{}).RunLengthEncode(eq).Size() != 0 {
+		t.Fail()
+	}
+}
+
+func TestConcatVectors(t *testing.T) {
+	if ConcatVectors().Size() != 0 {
+		t.Fail()
+	}
+	single := Vector{}.Append(1)
+	if ConcatVectors(single).Get(0) != 1 {
+		t.Fail()
+	}
+
+	var parts []Vector
+	total := 0
+	for _, size := range []int{0, 31, 1, 1000, 0, 33, 2000} {
+		var part Vector
+		for i := 0; i < size; i++ {
+			part = part.Append(total)
+			total++
+		}
+		parts = append(parts, part)
+	}
+	parts[3] = parts[3].Slice(0, parts[3].Size())
+
+	concatenated := ConcatVectors(parts...)
+	if concatenated.Size() != uint32(total) {
+		t.Fail()
+	}
+	for i := uint32(0); i < concatenated.Size(); i++ {
+		if concatenated.Get(i) != int(i) {
+			t.Fail()
+		}
+	}
+	if parts[1].Size() != 31 {
+		t.Fail()
+	}
+}
+
+func TestVectorConcat(t *testing.T) {
+	a := Vector{}.Append(1).Append(2)
+	b := Vector{}.Append(3)
+	c := a.Slice(1, 2).Concat(b)
+	if c.Size() != 2 || c.Get(0) != 2 || c.Get(1) != 3 {
+		t.Fail()
+	}
+	if a.Size() != 2 {
+		t.Fail()
+	}
+}
+
+func TestVectorIsSorted(t *testing.T) {
+	less := func(a, b interface{}) bool {
+		return a.(int) < b.(int)
+	}
+	var v Vector
+	v = v.Append(100)
+	for i := 0; i < 100; i++ {
+		v = v.Append(i / 2)
+	}
+	if v.IsSorted(less) {
+		t.Fail()
+	}
+	if !v.Slice(1, v.Size()).IsSorted(less) {
+		t.Fail()
+	}
+	if !v.Slice(0, 1).IsSorted(less) || !(Vector{}).IsSorted(less) {
+		t.Fail()
+	}
+	if v.Set(50, -1).Slice(1, v.Size()).IsSorted(less) {
+		t.Fail()
+	}
+}
+
+func TestVectorPartition(t *testing.T) {
+	var v Vector
+	for i := 0; i < 101; i++ {
+		v = v.Append(i)
+	}
+	v = v.Slice(1, v.Size())
+
+	even, odd := v.Partition(func(value interface{}) bool {
+		return value.(int)%2 == 0
+	})
+	if even.Size()+odd.Size() != v.Size() || even.Size() != 50 {
+		t.Fail()
+	}
+	for i := uint32(0); i < even.Size(); i++ {
+		if even.Get(i) != int(i*2+2) || odd.Get(i) != int(i*2+1) {
+			t.Fail()
+		}
+	}
+}
+
+func TestVectorToMap(t *testing.T) {
+	type record struct {
+		id   int
+		name string
+	}
+	var v Vector
+	for i := 0; i < 100; i++ {
+		v = v.Append(record{i % 50, fmt.Sprint(i)})
+	}
+	m := v.ToMap(func(index uint32, value interface{}) interface{} {
+		return value.(record).id
+	})
+	if m.Size() != 50 {
+		t.Fail()
+	}
+	for i := 0; i < 50; i++ {
+		value, ok := m.Get(i)
+		if !ok || value.(record).name != fmt.Sprint(i+50) {
+			t.Fail()
+		}
+	}
+}
+
+func TestVectorCountValues(t *testing.T) {
+	var v Vector
+	for _, value := range []string{"x", "a", "b", "a", "c", "a", "b"} {
+		v = v.Append(value)
+	}
+	counts := v.Slice(1, v.Size()).CountValues()
+	if counts.Size() != 3 || counts.Has("x") {
+		t.Fail()
+	}
+	expected := map[string]int{"a": 3, "b": 2, "c": 1}
+	for value, count := range expected {
+		if c, _ := counts.Get(value); c != count {
+			t.Fail()
+		}
+	}
+
+	counts = Vector{}.Resize(10).CountValues()
+	if c, _ := counts.Get(nil); c != 10 {
+		t.Fail()
+	}
+}
+
+func TestVectorEqualUnordered(t *testing.T) {
+	var a, b Vector
+	for _, value := range []int{1, 2, 2, 3} {
+		a = a.Append(value)
+	}
+	for _, value := range []int{0, 2, 3, 1, 2} {
+		b = b.Append(value)
+	}
+	if a.EqualUnordered(b) || !a.EqualUnordered(b.Slice(1, b.Size())) {
+		t.Fail()
+	}
+	if a.EqualUnordered(a.Set(1, 3)) {
+		t.Fail()
+	}
+	if a.EqualUnordered(a.Append(1)) || a.EqualUnordered(a.Slice(0, 3)) {
+		t.Fail()
+	}
+	if !(Vector{}).EqualUnordered(Vector{}) {
+		t.Fail()
+	}
+}
+
+func TestVectorChunkedReduce(t *testing.T) {
+	var v Vector
+	for i := 0; i < 250; i++ {
+		v = v.Append(i)
+	}
+	sums := v.ChunkedReduce(100, func(chunk Vector) interface{} {
+		sum := 0
+		r := chunk.Elements()
+		for r.Next() {
+			sum += r.Get().(int)
+		}
+		return sum
+	})
+	if sums.Size() != 3 {
+		t.Fail()
+	}
+	if sums.Get(0) != 4950 || sums.Get(1) != 14950 || sums.Get(2) != 11225 {
+		t.Fail()
+	}
+	if (Vector{}).ChunkedReduce(10, nil).Size() != 0 {
+		t.Fail()
+	}
+}
+
+func TestVectorDedupAdjacent(t *testing.T) {
+	var v Vector
+	for _, value := range []int{0, 1, 1, 2, 2, 2, 1, 3, 3} {
+		v = v.Append(value)
+	}
+	deduped := v.Slice(1, v.Size()).DedupAdjacent(func(a, b interface{}) bool {
+		return a == b
+	})
+	expected := []int{1, 2, 1, 3}
+	if deduped.Size() != uint32(len(expected)) {
+		t.Fail()
+	}
+	for i, e := range expected {
+		if deduped.Get(uint32(i)) != e {
+			t.Fail()
+		}
+	}
+}
+
+func TestVectorCursor(t *testing.T) {
+	v := Vector{}.Resize(5000)
+	for i := uint32(0); i < v.Size(); i += 3 {
+		v = v.Set(i, i)
+	}
+	v = v.Slice(7, 5000)
+
+	c := v.Cursor()
+	if c.Get() != v.Get(0) {
+		t.Fail()
+	}
+	rng := rand.New(rand.NewSource(4711))
+	for i := 0; i < 10000; i++ {
+		index := c.Index()
+		if i%10 == 0 {
+			index = uint32(rng.Intn(int(v.Size())))
+		} else if index+1 < v.Size() {
+			index++
+		}
+		c.Seek(index)
+		if c.Get() != v.Get(index) {
+			t.Fail()
+		}
+	}
+}
+
+func TestVectorCursorOutOfBounds(t *testing.T) {
+	c := Vector{}.Resize(10).Cursor()
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	c.Seek(10)
+}
+
+func TestVectorGrow(t *testing.T) {
+	v := Vector{}.Append(1)
+	grown := v.Grow(5000)
+	if grown.Size() != 1 || grown.Get(0) != 1 {
+		t.Fail()
+	}
+	if grown.Capacity() < 5001 || v.Capacity() >= 5001 {
+		t.Fail()
+	}
+
+	depth := grown.depth
+	capacity := grown.Capacity()
+	for i := 0; i < 5000; i++ {
+		grown = grown.Append(i)
+	}
+	if grown.depth != depth || grown.Capacity() != capacity {
+		t.Fail()
+	}
+	if grown.Get(5000) != 4999 {
+		t.Fail()
+	}
+	if (Vector{}).Grow(0).Capacity() != 0 {
+		t.Fail()
+	}
+}
+
+func TestVectorGrowBeyondCapacityFails(t *testing.T) {
+	for _, grow := range []func(){
+		func() { Vector{}.Grow(1 << 31) },
+		func() { Vector{}.Resize(10).Grow(math.MaxUint32) },
+		func() { Vector{}.Resize(1 << 31) },
+		func() { Vector{}.Resize(1 << 30).Slice(1, 1<<30).Resize(1 << 30) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fail()
+				}
+			}()
+			grow()
+		}()
+	}
+	if (Vector{}).Grow(1<<30).Capacity() != 1<<30 {
+		t.Fail()
+	}
+}
+
+func TestVectorWithLeadingCapacityOverflowFails(t *testing.T) {
+	defer func() {
+		if recover() != "Vector capacity exceeded" {
+			t.Fail()
+		}
+	}()
+	Vector{}.Append(1).WithLeadingCapacity(math.MaxUint32)
+}
+
 const (
 	numValues = 1024
 )
@@ -486,3 +1348,62 @@ func BenchmarkResizeImmutableVector(b *testing.B) {
 		}
 	})
 }
+
+func heavyMapping(index uint32, value interface{}) interface{} {
+	result := value.(int)
+	for i := 0; i < 1000; i++ {
+		result = (result*31 + i) % 1000003
+	}
+	return result
+}
+
+func BenchmarkMapImmutableVector(b *testing.B) {
+	v := Vector{}.Resize(numValues)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = v.Map(func(index uint32, value interface{}) interface{} {
+			return heavyMapping(index, int(index))
+		})
+	}
+}
+
+func BenchmarkMapParallelImmutableVector(b *testing.B) {
+	v := Vector{}.Resize(numValues)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = v.MapParallel(runtime.NumCPU(), func(index uint32, value interface{}) interface{} {
+			return heavyMapping(index, int(index))
+		})
+	}
+}
+
+func concatParts() []Vector {
+	parts := make([]Vector, 100)
+	for i := range parts {
+		parts[i] = Vector{}.Resize(100)
+	}
+	return parts
+}
+
+func BenchmarkConcatImmutableVector(b *testing.B) {
+	parts := concatParts()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var v Vector
+		for _, part := range parts {
+			v = v.Concat(part)
+		}
+	}
+}
+
+func BenchmarkConcatVectorsImmutableVector(b *testing.B) {
+	parts := concatParts()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = ConcatVectors(parts...)
+	}
+}
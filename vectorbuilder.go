@@ -0,0 +1,154 @@
+package immutable
+
+// VectorBuilder builds a Vector by modifying its storage in place,
+// avoiding the copying made by each Vector operation.
+// Storage shared with other vectors is copied before being
+// modified, so building never affects existing vectors.
+//
+// A VectorBuilder is not safe for concurrent use, and cannot be
+// used after Build has been called.
+//
+// The zero VectorBuilder is empty and ready for use.
+type VectorBuilder struct {
+	vector Vector
+	owner  *owner
+	built  bool
+}
+
+// owner marks storage nodes that a builder may modify in place.
+type owner struct {
+	_ byte
+}
+
+// NewVectorBuilder returns an empty VectorBuilder with storage
+// structure sized for capacity elements, so that appending up to
+// capacity elements never grows the structure.
+// Capacities beyond the maximum vector capacity of 2^30 elements
+// causes panic.
+func NewVectorBuilder(capacity uint32) *VectorBuilder {
+	return &VectorBuilder{
+		vector: Vector{}.Grow(capacity),
+	}
+}
+
+// Builder returns a VectorBuilder starting out with the elements
+// of the vector.
+func (v Vector) Builder() *VectorBuilder {
+	return &VectorBuilder{
+		vector: v,
+	}
+}
+
+// Set sets the element at the given index.
+// Out of bounds access causes panic.
+func (b *VectorBuilder) Set(index uint32, value interface{}) {
+	owner := b.edit()
+	if index >= b.vector.size {
+		panic("Out of bounds vector access")
+	}
+	b.vector = b.vector.set(index, value, owner)
+}
+
+// Get returns the element at the given index.
+// Out of bounds access causes panic.
+func (b *VectorBuilder) Get(index uint32) interface{} {
+	return b.vector.Get(index)
+}
+
+// Append adds an element to the end of the vector.
+func (b *VectorBuilder) Append(value interface{}) {
+	owner := b.edit()
+	size := b.vector.size
+	b.vector = b.vector.Resize(size+1).set(size, value, owner)
+}
+
+// Resize grows or shrinks the vector to the given size,
+// just like Vector.Resize.
+func (b *VectorBuilder) Resize(size uint32) {
+	b.edit()
+	b.vector = b.vector.Resize(size)
+}
+
+// Size returns the current vector size.
+func (b *VectorBuilder) Size() uint32 {
+	return b.vector.size
+}
+
+// Build returns the built Vector.
+// The builder cannot be used after calling Build.
+func (b *VectorBuilder) Build() Vector {
+	b.edit()
+	b.built = true
+	b.owner = nil
+	return b.vector
+}
+
+func (b *VectorBuilder) edit() *owner {
+	if b.built {
+		panic("VectorBuilder used after Build")
+	}
+	if b.owner == nil {
+		b.owner = &owner{}
+	}
+	return b.owner
+}
+
+// VectorFromChannel returns a vector with all elements received
+// from ch, in order, until it is closed.
+func VectorFromChannel(ch <-chan interface{}) Vector {
+	var builder VectorBuilder
+	for value := range ch {
+		builder.Append(value)
+	}
+	return builder.Build()
+}
+
+// VectorTxn collects the operations of a vector transaction,
+// see Vector.Transact.
+type VectorTxn struct {
+	builder *VectorBuilder
+}
+
+// Set sets the element at the given index of the transaction result.
+// Out of bounds access causes panic.
+func (txn *VectorTxn) Set(index uint32, value interface{}) {
+	txn.builder.Set(index, value)
+}
+
+// Get returns the element at the given index of the transaction
+// result, including the effects of operations made so far.
+// Out of bounds access causes panic.
+func (txn *VectorTxn) Get(index uint32) interface{} {
+	return txn.builder.Get(index)
+}
+
+// Append adds an element to the end of the transaction result.
+func (txn *VectorTxn) Append(value interface{}) {
+	txn.builder.Append(value)
+}
+
+// Resize grows or shrinks the transaction result,
+// just like Vector.Resize.
+func (txn *VectorTxn) Resize(size uint32) {
+	txn.builder.Resize(size)
+}
+
+// Size returns the current size of the transaction result.
+func (txn *VectorTxn) Size() uint32 {
+	return txn.builder.Size()
+}
+
+// Transact calls ops with a transaction, and returns the vector
+// resulting from applying all operations of the transaction.
+// Operations are applied using a builder, so no intermediate
+// vector versions are created.
+// If ops panics, for example by an out of bounds Set, no result
+// is returned and the original vector is unaffected.
+// The transaction cannot be used after ops returns.
+func (v Vector) Transact(ops func(txn *VectorTxn)) Vector {
+	txn := VectorTxn{
+		builder: v.Builder(),
+	}
+	ops(&txn)
+	return txn.builder.Build()
+}
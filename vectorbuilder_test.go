@@ -0,0 +1,170 @@
+package immutable
+
+import "testing"
+
+func TestVectorBuilderAppend(t *testing.T) {
+	var b VectorBuilder
+	for i := 0; i < 1000; i++ {
+		b.Append(i)
+	}
+	v := b.Build()
+	if v.Size() != 1000 {
+		t.Fail()
+	}
+	for i := uint32(0); i < v.Size(); i++ {
+		if v.Get(i) != int(i) {
+			t.Fail()
+		}
+	}
+}
+
+func TestVectorBuilderDoesNotAffectSource(t *testing.T) {
+	source := Vector{}.Append(1).Append(2)
+	b := source.Builder()
+	b.Set(0, 100)
+	b.Append(3)
+	built := b.Build()
+
+	if source.Size() != 2 || source.Get(0) != 1 {
+		t.Fail()
+	}
+	if built.Size() != 3 || built.Get(0) != 100 || built.Get(2) != 3 {
+		t.Fail()
+	}
+}
+
+func TestNewVectorBuilder(t *testing.T) {
+	b := NewVectorBuilder(5000)
+	depth := b.vector.depth
+	if b.Size() != 0 || b.vector.capacity < 5000 {
+		t.Fail()
+	}
+	for i := 0; i < 5000; i++ {
+		b.Append(i)
+	}
+	if b.vector.depth != depth {
+		t.Fail()
+	}
+	v := b.Build()
+	if v.Size() != 5000 {
+		t.Fail()
+	}
+	for i := uint32(0); i < v.Size(); i++ {
+		if v.Get(i) != int(i) {
+			t.Fail()
+		}
+	}
+	if NewVectorBuilder(0).Build().Size() != 0 {
+		t.Fail()
+	}
+}
+
+func TestNewVectorBuilderHugeCapacityFails(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	NewVectorBuilder(1 << 31)
+}
+
+func TestVectorBuilderUseAfterBuildFails(t *testing.T) {
+	var b VectorBuilder
+	b.Append(1)
+	b.Build()
+	defer func() {
+		if recover() == nil {
+			t.Fail()
+		}
+	}()
+	b.Append(2)
+}
+
+func TestVectorFromChannel(t *testing.T) {
+	ch := make(chan interface{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			ch <- i
+		}
+		close(ch)
+	}()
+	v := VectorFromChannel(ch)
+	if v.Size() != 1000 {
+		t.Fail()
+	}
+	for i := uint32(0); i < v.Size(); i++ {
+		if v.Get(i) != int(i) {
+			t.Fail()
+		}
+	}
+}
+
+func TestVectorTransact(t *testing.T) {
+	v := Vector{}.Append(1).Append(2).Append(3)
+	result := v.Transact(func(txn *VectorTxn) {
+		txn.Set(0, 10)
+		txn.Append(4)
+		txn.Resize(10)
+		txn.Set(9, 9)
+		if txn.Get(0) != 10 || txn.Size() != 10 {
+			t.Fail()
+		}
+	})
+
+	if result.Size() != 10 || result.Get(0) != 10 || result.Get(3) != 4 || result.Get(9) != 9 {
+		t.Fail()
+	}
+	if v.Size() != 3 || v.Get(0) != 1 {
+		t.Fail()
+	}
+}
+
+func TestVectorTransactOutOfBounds(t *testing.T) {
+	v := Vector{}.Append(1)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fail()
+			}
+		}()
+		v = v.Transact(func(txn *VectorTxn) {
+			txn.Set(0, 2)
+			txn.Set(1, 3)
+		})
+	}()
+	if v.Size() != 1 || v.Get(0) != 1 {
+		t.Fail()
+	}
+}
+
+func benchmarkVectorBuilderAppend(b *testing.B, newBuilder func() *VectorBuilder) {
+	b.ReportAllocs()
+	reroots := uint32(0)
+	for i := 0; i < b.N; i++ {
+		builder := newBuilder()
+		// The first append of an empty vector creates a root
+		// without re-rooting.
+		depth := builder.vector.depth
+		if depth == 0 {
+			depth = 1
+		}
+		for j := 0; j < numValues; j++ {
+			builder.Append(j)
+		}
+		reroots += builder.vector.depth - depth
+		builder.Build()
+	}
+	b.ReportMetric(float64(reroots)/float64(b.N), "reroots/op")
+}
+
+func BenchmarkAppendVectorBuilder(b *testing.B) {
+	benchmarkVectorBuilderAppend(b, func() *VectorBuilder {
+		return &VectorBuilder{}
+	})
+}
+
+func BenchmarkAppendPresizedVectorBuilder(b *testing.B) {
+	benchmarkVectorBuilderAppend(b, func() *VectorBuilder {
+		return NewVectorBuilder(numValues)
+	})
+}
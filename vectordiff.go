@@ -0,0 +1,73 @@
+package immutable
+
+// DiffKind is the kind of a DiffOp.
+type DiffKind int
+
+const (
+	// DiffEqual keeps an element present in both vectors.
+	DiffEqual DiffKind = iota
+	// DiffDelete removes an element of the original vector.
+	DiffDelete
+	// DiffInsert inserts an element of the other vector.
+	DiffInsert
+)
+
+// DiffOp is a single operation of a vector diff.
+type DiffOp struct {
+	Kind  DiffKind
+	Value interface{}
+}
+
+// LCSDiff returns the operations transforming v into other, based on
+// the longest common subsequence of the vectors.
+// Applying the operations in order, keeping equal elements, skipping
+// deleted elements and adding inserted elements, results in other.
+// Elements are compared using eq.
+//
+// The diff uses time and memory proportional to the product of the
+// vector sizes.
+func (v Vector) LCSDiff(other Vector, eq func(a, b interface{}) bool) []DiffOp {
+	a := v.values()
+	b := other.values()
+
+	// lcs[i][j] is the common subsequence length of a[i:] and b[j:]
+	lcs := make([][]uint32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]uint32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if eq(a[i], b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []DiffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case eq(a[i], b[j]):
+			ops = append(ops, DiffOp{DiffEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, DiffOp{DiffDelete, a[i]})
+			i++
+		default:
+			ops = append(ops, DiffOp{DiffInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, DiffOp{DiffDelete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, DiffOp{DiffInsert, b[j]})
+	}
+	return ops
+}
@@ -0,0 +1,75 @@
+package immutable
+
+import (
+	"strings"
+	"testing"
+)
+
+func vectorOfStrings(s string) Vector {
+	var v Vector
+	for _, r := range s {
+		v = v.Append(string(r))
+	}
+	return v
+}
+
+func renderDiff(ops []DiffOp) string {
+	var b strings.Builder
+	for _, op := range ops {
+		switch op.Kind {
+		case DiffEqual:
+			b.WriteString(" ")
+		case DiffDelete:
+			b.WriteString("-")
+		case DiffInsert:
+			b.WriteString("+")
+		}
+		b.WriteString(op.Value.(string))
+	}
+	return b.String()
+}
+
+func equalValues(a, b interface{}) bool {
+	return a == b
+}
+
+func TestLCSDiff(t *testing.T) {
+	a := vectorOfStrings("abcabba")
+	b := vectorOfStrings("cbabac")
+	ops := a.LCSDiff(b, equalValues)
+
+	var result Vector
+	common := 0
+	for _, op := range ops {
+		if op.Kind != DiffDelete {
+			result = result.Append(op.Value)
+		}
+		if op.Kind == DiffEqual {
+			common++
+		}
+	}
+	if common != 4 {
+		t.Fail()
+	}
+	if result.Size() != b.Size() || !result.EqualPrefix(b, b.Size()) {
+		t.Fail()
+	}
+}
+
+func TestLCSDiffSimple(t *testing.T) {
+	diff := renderDiff(vectorOfStrings("abc").LCSDiff(vectorOfStrings("abxc"), equalValues))
+	if diff != " a b+x c" {
+		t.Fail()
+	}
+	diff = renderDiff(vectorOfStrings("ab").LCSDiff(Vector{}, equalValues))
+	if diff != "-a-b" {
+		t.Fail()
+	}
+	diff = renderDiff(Vector{}.LCSDiff(vectorOfStrings("ab"), equalValues))
+	if diff != "+a+b" {
+		t.Fail()
+	}
+	if len(Vector{}.LCSDiff(Vector{}, equalValues)) != 0 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,69 @@
+package immutable
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+type vectorPayload struct {
+	Size   uint32
+	Values []vectorValue
+}
+
+type vectorValue struct {
+	Value interface{}
+}
+
+// MarshalBinary encodes the vector using gob.
+// Concrete element types need to be registered using gob.Register,
+// just as for any gob encoded interface values.
+func (v Vector) MarshalBinary() ([]byte, error) {
+	return v.marshal(v.size)
+}
+
+// MarshalBinaryCompact encodes the vector like MarshalBinary, but
+// leaves out trailing nil elements. This makes the encoding of
+// sparse vectors smaller.
+// The result is decoded using UnmarshalBinary.
+func (v Vector) MarshalBinaryCompact() ([]byte, error) {
+	used := v.size
+	for used > 0 && v.Get(used-1) == nil {
+		used--
+	}
+	return v.marshal(used)
+}
+
+func (v Vector) marshal(used uint32) ([]byte, error) {
+	payload := vectorPayload{
+		Size:   v.size,
+		Values: make([]vectorValue, used),
+	}
+	for i := uint32(0); i < used; i++ {
+		payload.Values[i].Value = v.Get(i)
+	}
+
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(payload)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a vector encoded by MarshalBinary or
+// MarshalBinaryCompact, replacing the contents of the receiver.
+func (v *Vector) UnmarshalBinary(data []byte) error {
+	var payload vectorPayload
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&payload)
+	if err != nil {
+		return err
+	}
+
+	var builder VectorBuilder
+	for _, value := range payload.Values {
+		builder.Append(value.Value)
+	}
+	builder.Resize(payload.Size)
+	*v = builder.Build()
+	return nil
+}
@@ -0,0 +1,56 @@
+package immutable
+
+import "testing"
+
+func TestVectorMarshalBinaryRoundTrip(t *testing.T) {
+	v := Vector{}.Append(1).Append("two").Append(nil).Append(4.0)
+	data, err := v.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Vector
+	err = decoded.UnmarshalBinary(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Size() != v.Size() {
+		t.Fail()
+	}
+	for i := uint32(0); i < v.Size(); i++ {
+		if decoded.Get(i) != v.Get(i) {
+			t.Fail()
+		}
+	}
+}
+
+func TestVectorMarshalBinaryCompact(t *testing.T) {
+	v := Vector{}.Resize(1000)
+	v = v.Set(10, 10)
+	v = v.Set(100, 100)
+
+	full, err := v.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	compact, err := v.MarshalBinaryCompact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compact) >= len(full) {
+		t.Fail()
+	}
+
+	var decoded Vector
+	err = decoded.UnmarshalBinary(compact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Size() != v.Size() {
+		t.Fail()
+	}
+	for i := uint32(0); i < v.Size(); i++ {
+		if decoded.Get(i) != v.Get(i) {
+			t.Fail()
+		}
+	}
+}